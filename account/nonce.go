@@ -0,0 +1,110 @@
+package account
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+)
+
+// number of concurrent PendingNonceAt requests while seeding
+const seedConcurrency = 16
+
+var nonceErrors = []string{
+	"nonce too low",
+	"nonce too high",
+	"already known",
+	"replacement transaction underpriced",
+}
+
+// NonceReader reads the pending nonces of the node. ethclient.Client is a
+// NonceReader.
+type NonceReader interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out nonces of the test accounts locally so that
+// transaction load does not query the node for every request.
+type NonceManager struct {
+	client NonceReader
+
+	mu     sync.Mutex
+	nonces map[common.Address]uint64
+}
+
+func NewNonceManager(ctx context.Context, client NonceReader, addresses []common.Address) (*NonceManager, error) {
+	nm := &NonceManager{
+		client: client,
+		nonces: make(map[common.Address]uint64),
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(seedConcurrency)
+	for _, address := range addresses {
+		address := address
+		g.Go(func() error {
+			return nm.Resync(gctx, address)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return nm, nil
+}
+
+// Next returns the nonce to use for the next transaction of address and
+// increments it. Unknown addresses are seeded from the pending state.
+func (nm *NonceManager) Next(ctx context.Context, address common.Address) (uint64, error) {
+	nm.mu.Lock()
+	nonce, ok := nm.nonces[address]
+	if ok {
+		nm.nonces[address] = nonce + 1
+		nm.mu.Unlock()
+		return nonce, nil
+	}
+	nm.mu.Unlock()
+
+	if err := nm.Resync(ctx, address); err != nil {
+		return 0, err
+	}
+	return nm.Next(ctx, address)
+}
+
+// Release hands back nonce, taken by Next for a transaction which is not
+// sent. It reports false when a later nonce of address was taken meanwhile,
+// so that nonce cannot be reused and address has to be resynced instead.
+func (nm *NonceManager) Release(address common.Address, nonce uint64) bool {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	if next, ok := nm.nonces[address]; !ok || next != nonce+1 {
+		return false
+	}
+	nm.nonces[address] = nonce
+	return true
+}
+
+// Resync resets the local nonce of address to the node's pending nonce.
+func (nm *NonceManager) Resync(ctx context.Context, address common.Address) error {
+	nonce, err := nm.client.PendingNonceAt(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	nm.nonces[address] = nonce
+	return nil
+}
+
+// IsNonceError reports whether the txpool rejected a transaction because
+// the local nonce went out of sync with the node.
+func IsNonceError(message string) bool {
+	for _, e := range nonceErrors {
+		if strings.Contains(message, e) {
+			return true
+		}
+	}
+	return false
+}
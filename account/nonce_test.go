@@ -0,0 +1,132 @@
+package account
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeNonceReader serves the pending nonces of a node.
+type fakeNonceReader struct {
+	mu      sync.Mutex
+	pending map[common.Address]uint64
+}
+
+func (f *fakeNonceReader) PendingNonceAt(_ context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending[account], nil
+}
+
+func (f *fakeNonceReader) set(account common.Address, nonce uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending[account] = nonce
+}
+
+func TestNonceManager(t *testing.T) {
+	alice := common.HexToAddress("0xa1")
+	bob := common.HexToAddress("0xb0")
+
+	tests := []struct {
+		name string
+		// pending nonce of alice on the node
+		pending uint64
+		// concurrent Next of alice
+		workers, perWorker int
+		// pending nonce of alice on the node before Resync, if any
+		resyncTo *uint64
+		// nonce of the Next after them
+		want uint64
+	}{
+		{name: "seeded", pending: 7, want: 7},
+		{name: "sequential", pending: 3, workers: 1, perWorker: 5, want: 8},
+		{name: "concurrent", pending: 0, workers: 16, perWorker: 100, want: 1600},
+		{name: "resync back", pending: 10, workers: 4, perWorker: 10, resyncTo: uint64Ptr(12), want: 12},
+		{name: "resync ahead", pending: 0, workers: 2, perWorker: 3, resyncTo: uint64Ptr(100), want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &fakeNonceReader{pending: map[common.Address]uint64{alice: tt.pending, bob: 42}}
+			nm, err := NewNonceManager(context.Background(), node, []common.Address{alice})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			seen := make(map[uint64]bool)
+			var wg sync.WaitGroup
+			for w := 0; w < tt.workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < tt.perWorker; i++ {
+						nonce, err := nm.Next(context.Background(), alice)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						mu.Lock()
+						if seen[nonce] {
+							t.Errorf("nonce %d handed out twice", nonce)
+						}
+						seen[nonce] = true
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			for nonce := tt.pending; nonce < tt.pending+uint64(tt.workers*tt.perWorker); nonce++ {
+				if !seen[nonce] {
+					t.Errorf("nonce %d skipped", nonce)
+				}
+			}
+
+			if tt.resyncTo != nil {
+				node.set(alice, *tt.resyncTo)
+				if err := nm.Resync(context.Background(), alice); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, _ := nm.Next(context.Background(), alice); got != tt.want {
+				t.Errorf("next nonce = %d, want %d", got, tt.want)
+			}
+			// addresses not seeded are read from the node on their first Next
+			if got, _ := nm.Next(context.Background(), bob); got != 42 {
+				t.Errorf("next nonce of unseeded = %d, want 42", got)
+			}
+		})
+	}
+}
+
+func TestNonceManagerRelease(t *testing.T) {
+	alice := common.HexToAddress("0xa1")
+	node := &fakeNonceReader{pending: map[common.Address]uint64{alice: 5}}
+	nm, err := NewNonceManager(context.Background(), node, []common.Address{alice})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := nm.Next(context.Background(), alice)
+	if !nm.Release(alice, first) {
+		t.Fatalf("release of the last nonce failed")
+	}
+	if got, _ := nm.Next(context.Background(), alice); got != first {
+		t.Errorf("next nonce after release = %d, want %d", got, first)
+	}
+
+	// a later nonce is taken, so the released one would leave a gap
+	nm.Next(context.Background(), alice)
+	if nm.Release(alice, first) {
+		t.Errorf("release of an earlier nonce succeeded")
+	}
+	if nm.Release(common.HexToAddress("0xb0"), 0) {
+		t.Errorf("release of an unknown address succeeded")
+	}
+}
+
+func uint64Ptr(n uint64) *uint64 {
+	return &n
+}
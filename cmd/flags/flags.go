@@ -1,8 +1,10 @@
 package flags
 
 import (
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
 	"github.com/urfave/cli/v2"
 
+	"github.com/tokamak-network/tokamak-trunks/metrics"
 	"github.com/tokamak-network/tokamak-trunks/reporter"
 	"github.com/tokamak-network/tokamak-trunks/utils"
 )
@@ -22,11 +24,60 @@ var (
 		EnvVars: utils.PrefixEnvVars(envPrefix, "L2_RPC"),
 		Value:   "http://localhost:9545",
 	}
+	L2WSFlag = &cli.StringFlag{
+		Name:    "l2-ws-url",
+		Usage:   "Connect L2 chain over WebSocket to subscribe new blocks",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "L2_WS"),
+	}
 	ScenarioFileFlag = &cli.PathFlag{
 		Name:    "scenario-file-path",
 		Usage:   "Scenario file path",
 		EnvVars: utils.PrefixEnvVars(envPrefix, "SCENARIO_FILE_PATH"),
 	}
+	L1AddressesFileFlag = &cli.PathFlag{
+		Name:    "l1-addresses-file-path",
+		Usage:   "L1 contract addresses file path (addresses.json) for bridge actions",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "L1_ADDRESSES_FILE_PATH"),
+	}
+	L1StandardBridgeFlag = &cli.StringFlag{
+		Name:    "l1-standard-bridge",
+		Usage:   "L1StandardBridge address for deposit action. Read from L1 contract addresses file if empty",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "L1_STANDARD_BRIDGE"),
+	}
+	L2ToL1MessagePasserFlag = &cli.StringFlag{
+		Name:    "l2-to-l1-message-passer",
+		Usage:   "L2ToL1MessagePasser address for withdrawal action",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "L2_TO_L1_MESSAGE_PASSER"),
+		Value:   predeploys.L2ToL1MessagePasser,
+	}
+	ProposerFlag = &cli.StringFlag{
+		Name:    "proposer",
+		Usage:   "Proposer address for withdrawal action. Read from L2OutputOracle if empty",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "PROPOSER"),
+	}
+	BaseFeeVaultFlag = &cli.StringFlag{
+		Name:    "base-fee-vault",
+		Usage:   "BaseFeeVault address to reconcile base fees",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "BASE_FEE_VAULT"),
+		Value:   predeploys.BaseFeeVault,
+	}
+	SequencerFeeVaultFlag = &cli.StringFlag{
+		Name:    "sequencer-fee-vault",
+		Usage:   "SequencerFeeVault address to reconcile priority fees",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "SEQUENCER_FEE_VAULT"),
+		Value:   predeploys.SequencerFeeVault,
+	}
+	L1FeeVaultFlag = &cli.StringFlag{
+		Name:    "l1-fee-vault",
+		Usage:   "L1FeeVault address to reconcile L1 fees",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "L1_FEE_VAULT"),
+		Value:   predeploys.L1FeeVault,
+	}
+	DashboardFlag = &cli.BoolFlag{
+		Name:    "dashboard",
+		Usage:   "Show the progress of the actions on a dashboard while a step runs",
+		EnvVars: utils.PrefixEnvVars(envPrefix, "DASHBOARD"),
+	}
 	L1ChainIdFlag = &cli.Uint64Flag{
 		Name:    "l1-chain-id",
 		Usage:   "L1 chain id",
@@ -42,11 +93,21 @@ var (
 var Flags = []cli.Flag{
 	L1RPCFlag,
 	L2RPCFlag,
+	L2WSFlag,
 	ScenarioFileFlag,
+	L1AddressesFileFlag,
+	L1StandardBridgeFlag,
+	L2ToL1MessagePasserFlag,
+	ProposerFlag,
+	BaseFeeVaultFlag,
+	SequencerFeeVaultFlag,
+	L1FeeVaultFlag,
+	DashboardFlag,
 	L1ChainIdFlag,
 	L2ChainIdFlag,
 }
 
 func init() {
 	Flags = append(Flags, reporter.CLIFlags(envPrefix)...)
+	Flags = append(Flags, metrics.CLIFlags(envPrefix)...)
 }
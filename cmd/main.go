@@ -9,6 +9,7 @@ import (
 
 	"github.com/tokamak-network/tokamak-trunks/account"
 	"github.com/tokamak-network/tokamak-trunks/cmd/flags"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
 	"github.com/tokamak-network/tokamak-trunks/trunks"
 )
 
@@ -23,6 +24,18 @@ func main() {
 			Flags:  flags.Flags,
 			Action: trunks.Main(),
 		},
+		{
+			Name:   "report",
+			Usage:  "rebuild reports from captured results",
+			Flags:  reporter.ReportCLIFlags("TOKAMAK_TRUNKS"),
+			Action: reporter.Main(),
+		},
+		{
+			Name:   "compare",
+			Usage:  "compare captured results with a baseline",
+			Flags:  reporter.CompareCLIFlags("TOKAMAK_TRUNKS"),
+			Action: reporter.CompareMain(),
+		},
 		{
 			Name:  "account",
 			Usage: "commands accounts for tx load test",
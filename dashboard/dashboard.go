@@ -0,0 +1,310 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mattn/go-isatty"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const (
+	refreshInterval = time.Second
+	// plain log lines are written less often than the dashboard is redrawn
+	logInterval = 10 * time.Second
+	// window of the rolling latency percentiles
+	latencyWindow = 10 * time.Second
+	// L2 blocks of the confirmed TPS
+	tpsBlocks = 10
+	topErrors = 3
+	// error messages are cut to keep a line of the dashboard on one line
+	maxErrorLength = 100
+)
+
+// Dashboard shows the progress of the actions of a step, redrawn in place
+// when stdout is a terminal, and as plain log lines otherwise.
+type Dashboard struct {
+	out       io.Writer
+	tty       bool
+	blockTime time.Duration
+
+	actions []*Action
+
+	// guards the drawing of the dashboard and of the printed lines
+	mu sync.Mutex
+	// lines drawn by the last refresh, to draw over them
+	lines int
+	frame string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(blockTime time.Duration) *Dashboard {
+	return &Dashboard{
+		out:       os.Stdout,
+		tty:       isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()),
+		blockTime: blockTime,
+		done:      make(chan struct{}),
+	}
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// Action follows the results and the transactions of an action.
+type Action struct {
+	name     string
+	duration time.Duration
+	pacer    vegeta.Pacer
+
+	mu        sync.Mutex
+	start     time.Time
+	requests  uint64
+	lastCount uint64
+	lastAt    time.Time
+	pending   int64
+	latencies []latencySample
+	errors    map[string]uint64
+	// confirmed transactions per L2 block
+	blocks map[uint64]uint64
+	head   uint64
+}
+
+// Add adds an action running for duration, observing the transactions
+// recorded in its report. pacer is nil for actions without pace. It is safe
+// to call on a nil Dashboard, like the methods of the returned Action.
+func (d *Dashboard) Add(name string, duration time.Duration, pacer vegeta.Pacer, report *reporter.Report) *Action {
+	if d == nil {
+		return nil
+	}
+	a := &Action{
+		name:     name,
+		duration: duration,
+		pacer:    pacer,
+		errors:   make(map[string]uint64),
+		blocks:   make(map[uint64]uint64),
+	}
+	report.Observe(a)
+	d.actions = append(d.actions, a)
+	return a
+}
+
+func (a *Action) Result(r *vegeta.Result) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests++
+	a.latencies = append(a.latencies, latencySample{at: time.Now(), latency: r.Latency})
+	if r.Error != "" {
+		a.errors[r.Error]++
+	}
+}
+
+func (a *Action) TransactionPending() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending++
+}
+
+func (a *Action) TransactionSettled() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending--
+}
+
+func (a *Action) TransactionConfirmed(receipt *types.Receipt) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	number := receipt.BlockNumber.Uint64()
+	a.blocks[number]++
+	if number > a.head {
+		a.head = number
+	}
+	for n := range a.blocks {
+		if n+tpsBlocks <= a.head {
+			delete(a.blocks, n)
+		}
+	}
+}
+
+// Start refreshes the dashboard until Stop, and prints the lines of
+// utils.Printf above it meanwhile.
+func (d *Dashboard) Start(start time.Time) {
+	if d == nil {
+		return
+	}
+	utils.SetPrinter(d.print)
+	for _, a := range d.actions {
+		a.start = start
+		a.lastAt = start
+	}
+	interval := refreshInterval
+	if !d.tty {
+		interval = logInterval
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.refresh()
+			case <-d.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop refreshes the dashboard a last time.
+func (d *Dashboard) Stop() {
+	if d == nil {
+		return
+	}
+	close(d.done)
+	d.wg.Wait()
+	d.refresh()
+	utils.SetPrinter(nil)
+}
+
+// print writes a line above the dashboard, redrawn under it.
+func (d *Dashboard) print(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.tty || d.lines == 0 {
+		fmt.Fprint(d.out, line)
+		return
+	}
+	fmt.Fprintf(d.out, "\033[%dA\033[J", d.lines)
+	fmt.Fprint(d.out, line)
+	fmt.Fprint(d.out, d.frame)
+}
+
+type actionStatus struct {
+	name      string
+	elapsed   time.Duration
+	remaining time.Duration
+	target    float64
+	achieved  float64
+	pending   int64
+	tps       float64
+	p50, p99  time.Duration
+	errors    []string
+}
+
+func (a *Action) status(now time.Time, blockTime time.Duration) actionStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := actionStatus{
+		name:    a.name,
+		elapsed: now.Sub(a.start).Truncate(time.Second),
+		pending: a.pending,
+	}
+	if a.duration > s.elapsed {
+		s.remaining = a.duration - s.elapsed
+	}
+	if a.pacer != nil && s.remaining > 0 {
+		s.target = a.pacer.Rate(now.Sub(a.start))
+	}
+	if seconds := now.Sub(a.lastAt).Seconds(); seconds > 0 {
+		s.achieved = float64(a.requests-a.lastCount) / seconds
+	}
+	a.lastCount, a.lastAt = a.requests, now
+
+	var confirmed uint64
+	for _, count := range a.blocks {
+		confirmed += count
+	}
+	if len(a.blocks) > 0 && blockTime > 0 {
+		s.tps = float64(confirmed) / (float64(tpsBlocks) * blockTime.Seconds())
+	}
+
+	i := 0
+	for i < len(a.latencies) && now.Sub(a.latencies[i].at) > latencyWindow {
+		i++
+	}
+	a.latencies = a.latencies[i:]
+	if len(a.latencies) > 0 {
+		sorted := make([]time.Duration, len(a.latencies))
+		for i, sample := range a.latencies {
+			sorted[i] = sample.latency
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s.p50 = sorted[len(sorted)*50/100]
+		s.p99 = sorted[len(sorted)*99/100]
+	}
+
+	errs := make([]string, 0, len(a.errors))
+	for e := range a.errors {
+		errs = append(errs, e)
+	}
+	sort.Slice(errs, func(i, j int) bool { return a.errors[errs[i]] > a.errors[errs[j]] })
+	if len(errs) > topErrors {
+		errs = errs[:topErrors]
+	}
+	for _, e := range errs {
+		count := a.errors[e]
+		if len(e) > maxErrorLength {
+			e = e[:maxErrorLength] + "..."
+		}
+		s.errors = append(s.errors, fmt.Sprintf("%dx %s", count, e))
+	}
+	return s
+}
+
+func (d *Dashboard) refresh() {
+	now := time.Now()
+	statuses := make([]actionStatus, len(d.actions))
+	for i, a := range d.actions {
+		statuses[i] = a.status(now, d.blockTime)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.tty {
+		for _, s := range statuses {
+			fmt.Fprintf(d.out, "%s elapsed=%s remaining=%s target=%.1f/s achieved=%.1f/s pending=%d tps=%.1f p50=%s p99=%s errors=%q\n",
+				s.name, s.elapsed, s.remaining, s.target, s.achieved, s.pending, s.tps, s.p50, s.p99, s.errors)
+		}
+		return
+	}
+
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Action\tElapsed\tRemaining\tTarget RPS\tAchieved RPS\tPending\tTPS (%d blocks)\tp50\tp99\n", tpsBlocks)
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f\t%.1f\t%d\t%.1f\t%s\t%s\n",
+			s.name, s.elapsed, s.remaining, s.target, s.achieved, s.pending, s.tps, s.p50, s.p99)
+	}
+	tw.Flush()
+	for _, s := range statuses {
+		for _, e := range s.errors {
+			fmt.Fprintf(&b, "%s: %s\n", s.name, e)
+		}
+	}
+
+	// move up over the last dashboard and clear it before drawing
+	if d.lines > 0 {
+		fmt.Fprintf(d.out, "\033[%dA\033[J", d.lines)
+	}
+	d.frame = b.String()
+	fmt.Fprint(d.out, d.frame)
+	d.lines = strings.Count(d.frame, "\n")
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/tokamak-network/tokamak-trunks/utils"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	addrName = "metrics-addr"
+)
+
+type CLIConfig struct {
+	Addr string
+}
+
+func CLIFlags(envPrefix string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    addrName,
+			Usage:   "Address to serve Prometheus metrics at /metrics during the test, e.g. :7301. Disabled if empty",
+			EnvVars: utils.PrefixEnvVars(envPrefix, "METRICS_ADDR"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context) CLIConfig {
+	return CLIConfig{
+		Addr: ctx.String(addrName),
+	}
+}
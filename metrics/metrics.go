@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const namespace = "trunks"
+
+// interval of updating the target rate of the pacers
+const rateInterval = time.Second
+
+type metrics struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	errors    *prometheus.CounterVec
+	pending   *prometheus.GaugeVec
+	confirmed *prometheus.CounterVec
+	rate      *prometheus.GaugeVec
+
+	server *http.Server
+}
+
+// m is nil when metrics are disabled
+var m *metrics
+
+// Start serves the metrics at /metrics of cfg.Addr until Stop. It does
+// nothing when cfg.Addr is empty.
+func Start(cfg CLIConfig) error {
+	if cfg.Addr == "" {
+		return nil
+	}
+	actionLabels := []string{"step", "action"}
+	methodLabels := []string{"step", "action", "method"}
+	mm := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Requests sent, counted when their result is known",
+		}, methodLabels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests, until confirmation for transactions",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 15),
+		}, methodLabels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_errors_total",
+			Help:      "Failed requests by JSON-RPC error code, or HTTP status code, 0 if none",
+		}, append(methodLabels, "code")),
+		pending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pending_transactions",
+			Help:      "Transactions accepted by the node and not confirmed yet",
+		}, actionLabels),
+		confirmed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "confirmed_transactions_total",
+			Help:      "Transactions confirmed successfully on L2",
+		}, actionLabels),
+		rate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "target_rate",
+			Help:      "Requests per second targeted by the pacer",
+		}, actionLabels),
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		mm.requests, mm.latency, mm.errors, mm.pending, mm.confirmed, mm.rate,
+	)
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen metrics on %s: %w", cfg.Addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mm.server = &http.Server{Handler: mux}
+	go func() {
+		if err := mm.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.Printf("metrics server stopped: %s\n", err)
+		}
+	}()
+	utils.Printf("serving metrics at http://%s/metrics\n", listener.Addr())
+	m = mm
+	return nil
+}
+
+func Stop() {
+	if m == nil {
+		return
+	}
+	m.server.Shutdown(context.Background())
+}
+
+// Action records the metrics of an action of a step. Its methods do nothing
+// when metrics are disabled.
+type Action struct {
+	step   string
+	name   string
+	pacer  vegeta.Pacer
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAction returns the metrics of an action, observing the transactions
+// recorded in its report. pacer is nil for actions without pace.
+func NewAction(step int, name string, pacer vegeta.Pacer, report *reporter.Report) *Action {
+	if m == nil {
+		return nil
+	}
+	a := &Action{
+		step:  strconv.Itoa(step),
+		name:  name,
+		pacer: pacer,
+	}
+	m.pending.WithLabelValues(a.step, a.name)
+	m.confirmed.WithLabelValues(a.step, a.name)
+	report.Observe(a)
+	return a
+}
+
+// Start updates the target rate of the pacer from start until Stop.
+func (a *Action) Start(start time.Time) {
+	if a == nil || a.pacer == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	rate := m.rate.WithLabelValues(a.step, a.name)
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(rateInterval)
+		defer ticker.Stop()
+		for {
+			rate.Set(a.pacer.Rate(time.Since(start)))
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop sets the target rate to zero after the action.
+func (a *Action) Stop() {
+	if a == nil || a.cancel == nil {
+		return
+	}
+	a.cancel()
+	a.wg.Wait()
+	m.rate.WithLabelValues(a.step, a.name).Set(0)
+}
+
+func (a *Action) Result(r *vegeta.Result) {
+	if a == nil {
+		return
+	}
+	method := reporter.RPCMethod(r)
+	m.requests.WithLabelValues(a.step, a.name, method).Inc()
+	m.latency.WithLabelValues(a.step, a.name, method).Observe(r.Latency.Seconds())
+	if r.Error != "" || r.Code < 200 || r.Code >= 400 {
+		// JSON-RPC error codes are negative, and kept in the uint16 code
+		code := strconv.Itoa(int(int16(r.Code)))
+		m.errors.WithLabelValues(a.step, a.name, method, code).Inc()
+	}
+}
+
+func (a *Action) TransactionPending() {
+	m.pending.WithLabelValues(a.step, a.name).Inc()
+}
+
+func (a *Action) TransactionSettled() {
+	m.pending.WithLabelValues(a.step, a.name).Dec()
+}
+
+func (a *Action) TransactionConfirmed(receipt *types.Receipt) {
+	m.confirmed.WithLabelValues(a.step, a.name).Inc()
+}
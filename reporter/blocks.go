@@ -0,0 +1,201 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// blocks using less than this share of their gas limit are under-filled
+const underfilledRatio = 0.5
+
+// selectors of the L1 info deposit of L1Block, in the format of Bedrock and Ecotone
+var (
+	l1InfoBedrockSelector = crypto.Keccak256([]byte("setL1BlockValues(uint64,uint64,uint256,bytes32,uint64,bytes32,uint256,uint256)"))[:4]
+	l1InfoEcotoneSelector = crypto.Keccak256([]byte("setL1BlockValuesEcotone()"))[:4]
+)
+
+// BlockReader reads the L2 blocks of a report. ethclient.Client is a BlockReader.
+type BlockReader interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// blockRow is an L2 block between the first and the last block confirming
+// transactions of an action.
+type blockRow struct {
+	Number   uint64   `json:"number"`
+	Time     uint64   `json:"time"`
+	Interval uint64   `json:"interval"`
+	Txs      uint64   `json:"txs"`
+	Ours     uint64   `json:"ours"`
+	GasUsed  uint64   `json:"gasUsed"`
+	GasLimit uint64   `json:"gasLimit"`
+	BaseFee  *big.Int `json:"baseFee"`
+	L1Origin uint64   `json:"l1Origin"`
+}
+
+func (b *blockRow) foreign() uint64 {
+	return b.Txs - b.Ours
+}
+
+// tps is the TPS of our transactions in the block, over the block interval,
+// or the L2 block time for the first block.
+func (b *blockRow) tps(l2BlockTime *big.Int) float64 {
+	interval := b.Interval
+	if interval == 0 && l2BlockTime != nil {
+		interval = l2BlockTime.Uint64()
+	}
+	if interval == 0 {
+		return 0
+	}
+	return float64(b.Ours) / float64(interval)
+}
+
+func (b *blockRow) underfilled() bool {
+	return float64(b.GasUsed) < float64(b.GasLimit)*underfilledRatio
+}
+
+// RecordBlocks reads every block between the first and the last confirmed
+// block of the action.
+func (r *Report) RecordBlocks(blocks BlockReader) error {
+	if r.receiptCount == 0 {
+		return nil
+	}
+	start, end := r.startBlockNumber.Uint64(), r.endBlockNumber.Uint64()
+	var prevTime uint64
+	for number := start; number <= end; number++ {
+		block, err := blocks.BlockByNumber(context.Background(), new(big.Int).SetUint64(number))
+		if err != nil {
+			return err
+		}
+		row := &blockRow{
+			Number:   number,
+			Time:     block.Time(),
+			Txs:      uint64(len(block.Transactions())),
+			GasUsed:  block.GasUsed(),
+			GasLimit: block.GasLimit(),
+			BaseFee:  block.BaseFee(),
+		}
+		if number > start {
+			row.Interval = row.Time - prevTime
+		}
+		prevTime = row.Time
+		if len(block.Transactions()) > 0 {
+			row.L1Origin = l1Origin(block.Transactions()[0])
+		}
+
+		r.mu.Lock()
+		row.Ours = r.l2TxsByBlock[number]
+		r.recordBlock(row)
+		r.capture(&record{Kind: blockRecord, Block: row})
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *Report) recordBlock(row *blockRow) {
+	r.blocks = append(r.blocks, row)
+}
+
+// l1Origin returns the L1 block number of the L1 info deposit that starts
+// every L2 block, in the format of Bedrock or Ecotone.
+func l1Origin(tx *types.Transaction) uint64 {
+	if !tx.IsDepositTx() {
+		return 0
+	}
+	data := tx.Data()
+	switch {
+	case bytes.HasPrefix(data, l1InfoBedrockSelector) && len(data) >= 36:
+		// the first arg is the number, as a uint64 padded to 32 bytes
+		return binary.BigEndian.Uint64(data[28:36])
+	case bytes.HasPrefix(data, l1InfoEcotoneSelector) && len(data) >= 36:
+		// packed after the fee scalars, sequence number and timestamp
+		return binary.BigEndian.Uint64(data[28:36])
+	}
+	return 0
+}
+
+type blockStats struct {
+	sustainedTPS float64
+	peakTPS      float64
+	empty        int
+	underfilled  int
+}
+
+// blockStats derives the sustained TPS, the median of the TPS per block,
+// the peak TPS, and the blocks without our transactions or under-filled.
+func (r *Report) blockStats() blockStats {
+	var s blockStats
+	if len(r.blocks) == 0 {
+		return s
+	}
+	tps := make([]float64, len(r.blocks))
+	for i, b := range r.blocks {
+		tps[i] = b.tps(r.l2BlockTime)
+		if tps[i] > s.peakTPS {
+			s.peakTPS = tps[i]
+		}
+		if b.Ours == 0 {
+			s.empty++
+		}
+		if b.underfilled() {
+			s.underfilled++
+		}
+	}
+	sort.Float64s(tps)
+	s.sustainedTPS = tps[len(tps)/2]
+	return s
+}
+
+func (r *Report) reportBlockStats(w io.Writer) error {
+	if len(r.blocks) == 0 {
+		return nil
+	}
+	s := r.blockStats()
+	const fmtstr = "Blocks\t%d\n" +
+		"  Sustained TPS (median per block)\t%.2f\n" +
+		"  Peak TPS\t%.2f\n" +
+		"  Blocks without our txs\t%d\n" +
+		"  Under-filled Blocks (<%.0f%% gas limit)\t%d\n"
+	_, err := fmt.Fprintf(w, fmtstr,
+		len(r.blocks), s.sustainedTPS, s.peakTPS, s.empty, underfilledRatio*100, s.underfilled,
+	)
+	return err
+}
+
+// BlocksReporter writes a row per block between the first and the last
+// confirmed block of the action.
+func (r *Report) BlocksReporter() vegeta.Reporter {
+	return func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		if _, err := fmt.Fprintf(tw, "Block\tInterval\tOur Txs\tForeign Txs\tGas Used\tGas Limit\tFill\tBase Fee\tL1 Origin\n"); err != nil {
+			return err
+		}
+		for _, b := range r.blocks {
+			fill := 0.0
+			if b.GasLimit > 0 {
+				fill = float64(b.GasUsed) / float64(b.GasLimit) * 100
+			}
+			// the interval of the first block is unknown
+			interval := "-"
+			if b.Interval > 0 {
+				interval = fmt.Sprintf("%ds", b.Interval)
+			}
+			if _, err := fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%.2f%%\t%d Wei\t%d\n",
+				b.Number, interval, b.Ours, b.foreign(), b.GasUsed, b.GasLimit, fill, b.BaseFee, b.L1Origin,
+			); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
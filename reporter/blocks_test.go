@@ -0,0 +1,40 @@
+package reporter
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestL1Origin(t *testing.T) {
+	// Bedrock: the number is the first arg, padded to 32 bytes
+	bedrock := make([]byte, 4+8*32)
+	copy(bedrock, hexutil.MustDecode("0x015d8eb9"))
+	binary.BigEndian.PutUint64(bedrock[28:36], 1234)
+	// Ecotone: packed after the scalars, the sequence number and the timestamp
+	ecotone := make([]byte, 164)
+	copy(ecotone, hexutil.MustDecode("0x440a5e20"))
+	binary.BigEndian.PutUint64(ecotone[28:36], 5678)
+
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+		want uint64
+	}{
+		{name: "bedrock", tx: types.NewTx(&types.DepositTx{Data: bedrock}), want: 1234},
+		{name: "ecotone", tx: types.NewTx(&types.DepositTx{Data: ecotone}), want: 5678},
+		{name: "short", tx: types.NewTx(&types.DepositTx{Data: bedrock[:20]})},
+		{name: "user deposit", tx: types.NewTx(&types.DepositTx{Data: []byte{1, 2, 3, 4}})},
+		{name: "not deposit", tx: types.NewTx(&types.DynamicFeeTx{To: &common.Address{}, Data: bedrock})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l1Origin(tt.tx); got != tt.want {
+				t.Errorf("l1Origin = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+// A results directory holds the manifest of a run and, per action, the
+// vegeta results in gob encoding and the records of its report as JSON lines.
+const (
+	manifestFile    = "manifest.json"
+	resultsSuffix   = ".results"
+	receiptsSuffix  = ".receipts"
+	manifestVersion = 1
+)
+
+const (
+	receiptRecord     = "receipt"
+	blobReceiptRecord = "blobReceipt"
+	confirmRecord     = "confirm"
+	latencyRecord     = "latency"
+	withdrawalRecord  = "withdrawal"
+	proposerRecord    = "proposer"
+	deploymentRecord  = "deployment"
+	batchRecord       = "batch"
+	headerRecord      = "header"
+	blockRecord       = "block"
+)
+
+// record is a call of a Record method of a Report, a header read for
+// RecordTPS and RecordL2FeeBreakdown, or a block row of RecordBlocks, so that
+// the report can be replayed.
+type record struct {
+	Kind     string         `json:"kind"`
+	Receipt  *types.Receipt `json:"receipt,omitempty"`
+	Header   *types.Header  `json:"header,omitempty"`
+	Block    *blockRow      `json:"block,omitempty"`
+	Stage    string         `json:"stage,omitempty"`
+	Latency  time.Duration  `json:"latency,omitempty"`
+	CodeSize int            `json:"codeSize,omitempty"`
+	GasUsed  uint64         `json:"gasUsed,omitempty"`
+	Elements int            `json:"elements,omitempty"`
+	Errors   []string       `json:"errors,omitempty"`
+	Outputs  uint64         `json:"outputs,omitempty"`
+	L1Txs    uint64         `json:"l1Txs,omitempty"`
+	L1Fee    *big.Int       `json:"l1Fee,omitempty"`
+}
+
+type manifest struct {
+	Version     int             `json:"version"`
+	L2BlockTime uint64          `json:"l2BlockTime"`
+	Steps       []*manifestStep `json:"steps"`
+}
+
+type manifestStep struct {
+	Step      int         `json:"step"`
+	Start     time.Time   `json:"start"`
+	Actions   []string    `json:"actions"`
+	FeeVaults []*feeVault `json:"feeVaults,omitempty"`
+}
+
+// capture must be called with r.mu held. A failed write stops capturing the
+// report rather than failing the action.
+func (r *Report) capture(rec *record) {
+	if r.records == nil {
+		return
+	}
+	if err := r.records.Encode(rec); err != nil {
+		utils.Printf("failed to capture %s record: %s\n", rec.Kind, err)
+		r.records = nil
+	}
+}
+
+// StepCapture writes the results and the report records of the actions of a
+// step to the results directory.
+type StepCapture struct {
+	files    []*os.File
+	encoders []vegeta.Encoder
+}
+
+func actionFile(dir string, step int, name, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%d-%s%s", step, name, suffix))
+}
+
+// CaptureStep starts capturing the actions of a step into their reports. It
+// returns nil when no results directory is configured.
+func (rm *reportManager) CaptureStep(step int, names []string, reports []*Report, start time.Time) (*StepCapture, error) {
+	if rm.resultsDir == "" {
+		return nil, nil
+	}
+	rm.manifest.Steps = append(rm.manifest.Steps, &manifestStep{
+		Step:    step,
+		Start:   start,
+		Actions: names,
+	})
+	if err := rm.writeManifest(); err != nil {
+		return nil, err
+	}
+
+	sc := &StepCapture{}
+	for i, name := range names {
+		results, err := os.Create(actionFile(rm.resultsDir, step, name, resultsSuffix))
+		if err != nil {
+			sc.Close()
+			return nil, err
+		}
+		sc.files = append(sc.files, results)
+		sc.encoders = append(sc.encoders, vegeta.NewEncoder(results))
+
+		receipts, err := os.Create(actionFile(rm.resultsDir, step, name, receiptsSuffix))
+		if err != nil {
+			sc.Close()
+			return nil, err
+		}
+		sc.files = append(sc.files, receipts)
+		reports[i].mu.Lock()
+		reports[i].records = json.NewEncoder(receipts)
+		reports[i].mu.Unlock()
+	}
+	return sc, nil
+}
+
+func (rm *reportManager) writeManifest() error {
+	file, err := os.Create(filepath.Join(rm.resultsDir, manifestFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rm.manifest)
+}
+
+// Result writes a result of the i-th action. It is safe to call on a nil
+// StepCapture, and from the goroutine of each action.
+func (sc *StepCapture) Result(i int, r *vegeta.Result) {
+	if sc == nil || sc.encoders[i] == nil {
+		return
+	}
+	if err := sc.encoders[i].Encode(r); err != nil {
+		utils.Printf("failed to capture result: %s\n", err)
+		sc.encoders[i] = nil
+	}
+}
+
+func (sc *StepCapture) Close() error {
+	if sc == nil {
+		return nil
+	}
+	var err error
+	for _, file := range sc.files {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// capturedHeaders serves the headers read during the run to a replayed report.
+type capturedHeaders map[uint64]*types.Header
+
+func (h capturedHeaders) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	header, ok := h[number.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("header %d is not captured", number)
+	}
+	return header, nil
+}
+
+type capturedStep struct {
+	step     int
+	timeline *Timeline
+	actions  []*capturedAction
+	vaults   []*feeVault
+}
+
+type capturedAction struct {
+	name    string
+	metrics *vegeta.Metrics
+	methods *MethodMetrics
+	report  *Report
+}
+
+// loadRun replays the steps captured in the results directory, with timelines
+// of the given bucket.
+func loadRun(dir string, bucket time.Duration) ([]*capturedStep, error) {
+	file, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(file, &m); err != nil {
+		return nil, err
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported results version %d", m.Version)
+	}
+	l2BlockTime := new(big.Int).SetUint64(m.L2BlockTime)
+
+	var steps []*capturedStep
+	for _, step := range m.Steps {
+		cs := &capturedStep{
+			step:     step.Step,
+			timeline: NewTimeline(step.Actions, bucket),
+			vaults:   step.FeeVaults,
+		}
+		cs.timeline.Start(step.Start)
+		for _, name := range step.Actions {
+			ca := &capturedAction{
+				name:    name,
+				metrics: &vegeta.Metrics{},
+				methods: NewMethodMetrics(),
+			}
+			err := decodeResults(actionFile(dir, step.Step, name, resultsSuffix), func(res *vegeta.Result) {
+				ca.metrics.Add(res)
+				ca.methods.Add(RPCMethod(res), res)
+				cs.timeline.Add(name, res)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("results of %s: %w", name, err)
+			}
+			ca.metrics.Close()
+			ca.methods.Close()
+
+			ca.report = NewReport(l2BlockTime)
+			headers, err := ca.report.replay(actionFile(dir, step.Step, name, receiptsSuffix))
+			if err != nil {
+				return nil, fmt.Errorf("receipts of %s: %w", name, err)
+			}
+			if err := ca.report.RecordTPS(headers); err != nil {
+				return nil, err
+			}
+			if err := ca.report.RecordL2FeeBreakdown(headers); err != nil {
+				return nil, err
+			}
+			cs.actions = append(cs.actions, ca)
+		}
+		steps = append(steps, cs)
+	}
+	return steps, nil
+}
+
+// Rebuild reports the actions captured in the results directory again, with
+// the format and timeline bucket of the report manager.
+func (rm *reportManager) Rebuild(dir string) error {
+	steps, err := loadRun(dir, rm.timelineBucket)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		reports := make([]*Report, len(step.actions))
+		for i, a := range step.actions {
+			if err := rm.ReportAction(step.step, a.name, a.metrics, a.methods, a.report); err != nil {
+				return err
+			}
+			reports[i] = a.report
+		}
+		if err := rm.reportFeeVaults(step.step, step.vaults, reports); err != nil {
+			return err
+		}
+		if err := rm.ReportTimeline(step.step, step.timeline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeResults reads results in any encoding of vegeta, so that files
+// converted with vegeta encode can be read too.
+func decodeResults(path string, add func(*vegeta.Result)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	decoder := vegeta.DecoderFor(file)
+	if decoder == nil {
+		return fmt.Errorf("unknown encoding of %s", path)
+	}
+	for {
+		var res vegeta.Result
+		if err := decoder.Decode(&res); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		add(&res)
+	}
+}
+
+// replay calls the Record methods of the report with the records of the
+// receipts file, and returns the headers read during the run.
+func (r *Report) replay(path string) (capturedHeaders, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	headers := make(capturedHeaders)
+	decoder := json.NewDecoder(file)
+	for {
+		var rec record
+		if err := decoder.Decode(&rec); err == io.EOF {
+			return headers, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch rec.Kind {
+		case receiptRecord:
+			r.RecordReceipt(rec.Receipt)
+		case blobReceiptRecord:
+			r.RecordBlobReceipt(rec.Receipt)
+		case confirmRecord:
+			r.RecordConfirmRequest()
+		case latencyRecord:
+			r.RecordInclusionLatency(rec.Stage, rec.Latency)
+		case withdrawalRecord:
+			r.RecordInitiatedWithdrawal()
+		case proposerRecord:
+			l1Fee := rec.L1Fee
+			if l1Fee == nil {
+				l1Fee = big.NewInt(0)
+			}
+			r.RecordProposer(rec.Outputs, rec.L1Txs, l1Fee)
+		case deploymentRecord:
+			r.RecordDeployment(rec.CodeSize, rec.GasUsed)
+		case batchRecord:
+			r.RecordBatch(rec.Elements, rec.Errors)
+		case headerRecord:
+			headers[rec.Header.Number.Uint64()] = rec.Header
+		case blockRecord:
+			r.recordBlock(rec.Block)
+		default:
+			return nil, fmt.Errorf("unknown record %q", rec.Kind)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package reporter
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testdata/run is a captured step without L2 block time: "transfer" confirmed
+// two transactions in block 10, "idle" confirmed none.
+func TestLoadRun(t *testing.T) {
+	steps, err := loadRun("testdata/run", time.Second)
+	if err != nil {
+		t.Fatalf("loadRun: %s", err)
+	}
+	if len(steps) != 1 || len(steps[0].actions) != 2 {
+		t.Fatalf("want 1 step of 2 actions, got %d steps", len(steps))
+	}
+
+	tests := []struct {
+		name      string
+		requests  uint64
+		receipts  uint64
+		tps       int64
+		l2BaseFee int64
+	}{
+		// both in one block, over the default block time
+		{name: "transfer", requests: 2, receipts: 2, tps: 1, l2BaseFee: 400 * 2 * 21000},
+		{name: "idle", requests: 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := steps[0].actions[i]
+			if a.name != tt.name {
+				t.Fatalf("action %d is %s, want %s", i, a.name, tt.name)
+			}
+			if a.metrics.Requests != tt.requests {
+				t.Errorf("requests = %d, want %d", a.metrics.Requests, tt.requests)
+			}
+			if a.report.receiptCount != tt.receipts {
+				t.Errorf("receipts = %d, want %d", a.report.receiptCount, tt.receipts)
+			}
+			if a.report.tps.Cmp(big.NewInt(tt.tps)) != 0 {
+				t.Errorf("tps = %s, want %d", a.report.tps, tt.tps)
+			}
+			if a.report.l2BaseFee.Cmp(big.NewInt(tt.l2BaseFee)) != 0 {
+				t.Errorf("l2 base fee = %s, want %d", a.report.l2BaseFee, tt.l2BaseFee)
+			}
+		})
+	}
+}
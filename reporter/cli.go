@@ -1,32 +1,83 @@
 package reporter
 
 import (
+	"os"
+	"time"
+
 	"github.com/tokamak-network/tokamak-trunks/utils"
 	"github.com/urfave/cli/v2"
 )
 
 const (
-	l2BlockTimeName = "l2-block-time"
-	outputFileName  = "output-file-name"
+	l2BlockTimeName    = "l2-block-time"
+	outputFileName     = "output-file-name"
+	reportFormat       = "report-format"
+	resultsDirName     = "results-dir"
+	timelineBucketName = "timeline-bucket"
+
+	currentName            = "current"
+	baselineName           = "baseline"
+	maxTPSDropName         = "max-tps-drop"
+	maxConfirmedDropName   = "max-confirmed-drop"
+	maxLatencyIncreaseName = "max-latency-increase"
+	maxErrorIncreaseName   = "max-error-ratio-increase"
+	maxFeeIncreaseName     = "max-fee-increase"
 )
 
 type CLIConfig struct {
 	L2BlockTime    uint64
 	outputFileName string
+	reportFormat   string
+	resultsDir     string
+	timelineBucket time.Duration
 }
 
 func CLIFlags(envPrefix string) []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		&cli.Uint64Flag{
 			Name:    l2BlockTimeName,
 			Usage:   "L2Block time",
 			EnvVars: utils.PrefixEnvVars(envPrefix, "L2_BLOCK_TIME"),
 		},
+		&cli.PathFlag{
+			Name:    resultsDirName,
+			Usage:   "Directory to capture the raw results and receipts of every action",
+			EnvVars: utils.PrefixEnvVars(envPrefix, "RESULTS_DIR"),
+		},
+	}, outputFlags(envPrefix)...)
+}
+
+// ReportCLIFlags are the flags of rebuilding reports from captured results.
+func ReportCLIFlags(envPrefix string) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.PathFlag{
+			Name:     resultsDirName,
+			Usage:    "Directory of the captured results and receipts",
+			EnvVars:  utils.PrefixEnvVars(envPrefix, "RESULTS_DIR"),
+			Required: true,
+		},
+	}, outputFlags(envPrefix)...)
+}
+
+func outputFlags(envPrefix string) []cli.Flag {
+	return []cli.Flag{
 		&cli.StringFlag{
 			Name:    outputFileName,
 			Usage:   "Output file name",
 			EnvVars: utils.PrefixEnvVars(envPrefix, "OUTPUT_FILE_NAME"),
 		},
+		&cli.StringFlag{
+			Name:    reportFormat,
+			Usage:   "Report format, text, json or html",
+			Value:   TextFormat,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "REPORT_FORMAT"),
+		},
+		&cli.DurationFlag{
+			Name:    timelineBucketName,
+			Usage:   "Bucket width of the timeline of parallel actions",
+			Value:   time.Second,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "TIMELINE_BUCKET"),
+		},
 	}
 }
 
@@ -34,5 +85,103 @@ func ReadCLIConfig(ctx *cli.Context) CLIConfig {
 	return CLIConfig{
 		L2BlockTime:    ctx.Uint64(l2BlockTimeName),
 		outputFileName: ctx.String(outputFileName),
+		reportFormat:   ctx.String(reportFormat),
+		resultsDir:     ctx.Path(resultsDirName),
+		timelineBucket: ctx.Duration(timelineBucketName),
+	}
+}
+
+// Main rebuilds the reports of the results captured by a run.
+func Main() cli.ActionFunc {
+	return func(cliCtx *cli.Context) error {
+		cfg := NewConfig(ReadCLIConfig(cliCtx))
+		dir := cfg.resultsDir
+		// the results are read, not captured again
+		cfg.resultsDir = ""
+		if err := InitReporter(cfg); err != nil {
+			return err
+		}
+		if err := reportMgr.Rebuild(dir); err != nil {
+			reportMgr.Close()
+			return err
+		}
+		return reportMgr.Close()
+	}
+}
+
+// CompareCLIFlags are the flags of comparing captured results with a baseline.
+func CompareCLIFlags(envPrefix string) []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:     currentName,
+			Usage:    "Results directory of the current run",
+			EnvVars:  utils.PrefixEnvVars(envPrefix, "CURRENT"),
+			Required: true,
+		},
+		&cli.PathFlag{
+			Name:     baselineName,
+			Usage:    "Results directory of the baseline run",
+			EnvVars:  utils.PrefixEnvVars(envPrefix, "BASELINE"),
+			Required: true,
+		},
+		&cli.Float64Flag{
+			Name:    maxTPSDropName,
+			Usage:   "Allowed TPS drop in percent, not checked if negative",
+			Value:   10,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "MAX_TPS_DROP"),
+		},
+		&cli.Float64Flag{
+			Name:    maxConfirmedDropName,
+			Usage:   "Allowed confirmed tx drop in percent, not checked if negative",
+			Value:   10,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "MAX_CONFIRMED_DROP"),
+		},
+		&cli.Float64Flag{
+			Name:    maxLatencyIncreaseName,
+			Usage:   "Allowed p50, p90 and p99 latency increase in percent, not checked if negative",
+			Value:   20,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "MAX_LATENCY_INCREASE"),
+		},
+		&cli.Float64Flag{
+			Name:    maxErrorIncreaseName,
+			Usage:   "Allowed error ratio increase in percentage points, not checked if negative",
+			Value:   1,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "MAX_ERROR_RATIO_INCREASE"),
+		},
+		&cli.Float64Flag{
+			Name:    maxFeeIncreaseName,
+			Usage:   "Allowed fee per tx increase in percent, not checked if negative",
+			Value:   10,
+			EnvVars: utils.PrefixEnvVars(envPrefix, "MAX_FEE_INCREASE"),
+		},
+	}
+}
+
+// CompareMain writes the comparison of a run with a baseline to stdout, and
+// fails when it regressed.
+func CompareMain() cli.ActionFunc {
+	return func(cliCtx *cli.Context) error {
+		thresholds := &Thresholds{
+			TPSDrop:            cliCtx.Float64(maxTPSDropName),
+			ConfirmedDrop:      cliCtx.Float64(maxConfirmedDropName),
+			LatencyIncrease:    cliCtx.Float64(maxLatencyIncreaseName),
+			ErrorRatioIncrease: cliCtx.Float64(maxErrorIncreaseName),
+			FeeIncrease:        cliCtx.Float64(maxFeeIncreaseName),
+		}
+		comparison, err := Compare(
+			utils.ConvertToAbsPath(cliCtx.Path(currentName)),
+			utils.ConvertToAbsPath(cliCtx.Path(baselineName)),
+			thresholds,
+		)
+		if err != nil {
+			return err
+		}
+		if err := comparison.Reporter().Report(os.Stdout); err != nil {
+			return err
+		}
+		if comparison.Regressed() {
+			return cli.Exit("performance regression against the baseline", 1)
+		}
+		return nil
 	}
 }
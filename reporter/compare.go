@@ -0,0 +1,245 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"text/tabwriter"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// Thresholds are the changes from the baseline allowed before a run is a
+// regression, in percent of the baseline, or in percentage points for the
+// error ratio. A negative threshold is not checked.
+type Thresholds struct {
+	TPSDrop            float64
+	ConfirmedDrop      float64
+	LatencyIncrease    float64
+	ErrorRatioIncrease float64
+	FeeIncrease        float64
+}
+
+type metricCheck struct {
+	name  string
+	value func(a *capturedAction) float64
+	unit  string
+	// whether an increase is worse than a decrease
+	increaseWorse bool
+	// whether the threshold is in percentage points instead of percent
+	points    bool
+	threshold func(t *Thresholds) float64
+}
+
+func latencyCheck(name string, quantile func(l *vegeta.LatencyMetrics) time.Duration) metricCheck {
+	return metricCheck{
+		name: name,
+		value: func(a *capturedAction) float64 {
+			return float64(quantile(&a.metrics.Latencies)) / float64(time.Millisecond)
+		},
+		unit:          "ms",
+		increaseWorse: true,
+		threshold:     func(t *Thresholds) float64 { return t.LatencyIncrease },
+	}
+}
+
+var metricChecks = []metricCheck{
+	{
+		name:      "TPS",
+		value:     func(a *capturedAction) float64 { return a.report.exactTPS() },
+		unit:      "tx/s",
+		threshold: func(t *Thresholds) float64 { return t.TPSDrop },
+	},
+	{
+		name:      "Confirmed Tx",
+		value:     func(a *capturedAction) float64 { return bigFloat(a.report.totalConfirmTransactions) },
+		threshold: func(t *Thresholds) float64 { return t.ConfirmedDrop },
+	},
+	latencyCheck("Latency p50", func(l *vegeta.LatencyMetrics) time.Duration { return l.P50 }),
+	latencyCheck("Latency p90", func(l *vegeta.LatencyMetrics) time.Duration { return l.P90 }),
+	latencyCheck("Latency p99", func(l *vegeta.LatencyMetrics) time.Duration { return l.P99 }),
+	{
+		name: "Error Ratio",
+		value: func(a *capturedAction) float64 {
+			if a.metrics.Requests == 0 {
+				return 0
+			}
+			return (1 - a.metrics.Success) * 100
+		},
+		unit:          "%",
+		increaseWorse: true,
+		points:        true,
+		threshold:     func(t *Thresholds) float64 { return t.ErrorRatioIncrease },
+	},
+	{
+		name: "Fee Per Tx",
+		value: func(a *capturedAction) float64 {
+			r := a.report
+			if r.receiptCount == 0 {
+				return 0
+			}
+			fee := new(big.Int).Add(r.l1Fee, r.l2Fee)
+			return bigFloat(fee) / float64(r.receiptCount) / 1e9
+		},
+		unit:          "Gwei",
+		increaseWorse: true,
+		threshold:     func(t *Thresholds) float64 { return t.FeeIncrease },
+	},
+}
+
+func bigFloat(x *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(x).Float64()
+	return f
+}
+
+type comparedMetric struct {
+	action   string
+	name     string
+	unit     string
+	baseline float64
+	current  float64
+	points   bool
+	breached bool
+}
+
+// Comparison is the comparison of the actions of a run with a baseline run
+// of the same scenario.
+type Comparison struct {
+	metrics []comparedMetric
+	// actions of the baseline missing in the current run
+	missing []string
+}
+
+// Compare compares the results captured in two results directories.
+func Compare(current, baseline string, t *Thresholds) (*Comparison, error) {
+	currentSteps, err := loadRun(current, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("current: %w", err)
+	}
+	baselineSteps, err := loadRun(baseline, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+
+	currentActions := make(map[string]*capturedAction)
+	for _, step := range currentSteps {
+		for _, a := range step.actions {
+			currentActions[fmt.Sprintf("%d. %s", step.step, a.name)] = a
+		}
+	}
+
+	c := &Comparison{}
+	for _, step := range baselineSteps {
+		for _, b := range step.actions {
+			name := fmt.Sprintf("%d. %s", step.step, b.name)
+			a, ok := currentActions[name]
+			if !ok {
+				c.missing = append(c.missing, name)
+				continue
+			}
+			for _, check := range metricChecks {
+				m := comparedMetric{
+					action:   name,
+					name:     check.name,
+					unit:     check.unit,
+					baseline: check.value(b),
+					current:  check.value(a),
+					points:   check.points,
+				}
+				// metrics not measured by the action, like TPS of calls
+				if m.baseline == 0 && m.current == 0 {
+					continue
+				}
+				m.breached = check.breached(m.delta(), check.threshold(t))
+				c.metrics = append(c.metrics, m)
+			}
+		}
+	}
+	return c, nil
+}
+
+// delta is the change from the baseline in percent, or in percentage points.
+// A change from a zero baseline is infinite in its direction, so that any
+// worsening from zero breaches the threshold.
+func (m *comparedMetric) delta() float64 {
+	if m.points {
+		return m.current - m.baseline
+	}
+	if m.baseline == 0 {
+		if m.current > 0 {
+			return math.Inf(1)
+		}
+		if m.current < 0 {
+			return math.Inf(-1)
+		}
+		return 0
+	}
+	return (m.current - m.baseline) / m.baseline * 100
+}
+
+func (check *metricCheck) breached(delta, threshold float64) bool {
+	if threshold < 0 {
+		return false
+	}
+	if check.increaseWorse {
+		return delta > threshold
+	}
+	return -delta > threshold
+}
+
+// Regressed reports whether a threshold is breached or an action is missing.
+func (c *Comparison) Regressed() bool {
+	if len(c.missing) > 0 {
+		return true
+	}
+	for _, m := range c.metrics {
+		if m.breached {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Comparison) Reporter() vegeta.Reporter {
+	return func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		if _, err := fmt.Fprintf(tw, "Action\tMetric\tBaseline\tCurrent\tDelta\tVerdict\n"); err != nil {
+			return err
+		}
+		for _, m := range c.metrics {
+			delta := fmt.Sprintf("%+.2f%%", m.delta())
+			if m.points {
+				delta = fmt.Sprintf("%+.2fpp", m.delta())
+			} else if m.baseline == 0 {
+				delta = "new"
+			}
+			verdict := "ok"
+			if m.breached {
+				verdict = "REGRESSION"
+			}
+			if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				m.action, m.name, formatMetric(m.baseline, m.unit), formatMetric(m.current, m.unit), delta, verdict,
+			); err != nil {
+				return err
+			}
+		}
+		for _, name := range c.missing {
+			if _, err := fmt.Fprintf(tw, "%s\t-\t-\t-\t-\tMISSING\n", name); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
+
+func formatMetric(v float64, unit string) string {
+	switch unit {
+	case "":
+		return fmt.Sprintf("%.0f", v)
+	case "%":
+		return fmt.Sprintf("%.2f%%", v)
+	}
+	return fmt.Sprintf("%.2f %s", v, unit)
+}
@@ -0,0 +1,53 @@
+package reporter
+
+import (
+	"testing"
+)
+
+func findCheck(t *testing.T, name string) *metricCheck {
+	t.Helper()
+	for i := range metricChecks {
+		if metricChecks[i].name == name {
+			return &metricChecks[i]
+		}
+	}
+	t.Fatalf("no metric check %q", name)
+	return nil
+}
+
+func TestBreached(t *testing.T) {
+	tests := []struct {
+		name              string
+		check             string
+		baseline, current float64
+		threshold         float64
+		want              bool
+	}{
+		{name: "tps within drop", check: "TPS", baseline: 100, current: 95, threshold: 10},
+		{name: "tps at drop", check: "TPS", baseline: 100, current: 90, threshold: 10},
+		{name: "tps beyond drop", check: "TPS", baseline: 100, current: 80, threshold: 10, want: true},
+		{name: "tps increase", check: "TPS", baseline: 100, current: 150, threshold: 10},
+		{name: "tps collapse to zero", check: "TPS", baseline: 198.3, current: 0, threshold: 10, want: true},
+		{name: "tps from zero baseline", check: "TPS", baseline: 0, current: 50, threshold: 10},
+		{name: "confirmed collapse to zero", check: "Confirmed Tx", baseline: 1983, current: 0, threshold: 10, want: true},
+		{name: "latency beyond increase", check: "Latency p50", baseline: 100, current: 130, threshold: 20, want: true},
+		{name: "latency within increase", check: "Latency p99", baseline: 100, current: 110, threshold: 20},
+		{name: "latency from zero baseline", check: "Latency p90", baseline: 0, current: 5, threshold: 20, want: true},
+		{name: "latency collapse to zero", check: "Latency p50", baseline: 100, current: 0, threshold: 20},
+		{name: "fee from zero baseline", check: "Fee Per Tx", baseline: 0, current: 0.02, threshold: 10, want: true},
+		{name: "error ratio beyond points", check: "Error Ratio", baseline: 1, current: 4, threshold: 2, want: true},
+		{name: "error ratio from zero within points", check: "Error Ratio", baseline: 0, current: 1, threshold: 2},
+		{name: "error ratio from zero beyond points", check: "Error Ratio", baseline: 0, current: 3, threshold: 2, want: true},
+		{name: "not checked", check: "TPS", baseline: 100, current: 0, threshold: -1},
+		{name: "not checked from zero baseline", check: "Latency p50", baseline: 0, current: 5, threshold: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := findCheck(t, tt.check)
+			m := comparedMetric{baseline: tt.baseline, current: tt.current, points: check.points}
+			if got := check.breached(m.delta(), tt.threshold); got != tt.want {
+				t.Errorf("breached = %v, want %v (delta %v)", got, tt.want, m.delta())
+			}
+		})
+	}
+}
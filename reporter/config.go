@@ -1,15 +1,30 @@
 package reporter
 
-import "math/big"
+import (
+	"math/big"
+	"time"
+
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
 
 type Config struct {
-	l2BlockTime *big.Int
-	filename    string
+	l2BlockTime    *big.Int
+	filename       string
+	format         string
+	resultsDir     string
+	timelineBucket time.Duration
 }
 
 func NewConfig(cfg CLIConfig) *Config {
+	resultsDir := cfg.resultsDir
+	if resultsDir != "" {
+		resultsDir = utils.ConvertToAbsPath(resultsDir)
+	}
 	return &Config{
-		l2BlockTime: new(big.Int).SetUint64(cfg.L2BlockTime),
-		filename:    cfg.outputFileName,
+		l2BlockTime:    new(big.Int).SetUint64(cfg.L2BlockTime),
+		filename:       cfg.outputFileName,
+		format:         cfg.reportFormat,
+		resultsDir:     resultsDir,
+		timelineBucket: cfg.timelineBucket,
 	}
 }
@@ -0,0 +1,246 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tsenart/vegeta/v12/lib/plot"
+)
+
+// htmlDocument collects the charts of every step and the text report, and
+// renders them in one page with the dygraphs assets embedded in vegeta, so the
+// page does not load anything from the network.
+type htmlDocument struct {
+	steps []*htmlStep
+	text  bytes.Buffer
+
+	// reports of the actions of the current step, until its timeline is reported
+	names   []string
+	reports []*Report
+}
+
+type htmlStep struct {
+	Step   int
+	Charts []*htmlChart
+}
+
+type htmlChart struct {
+	ID       string
+	Title    string
+	YLabel   string
+	LogScale bool
+	Labels   []string
+	Data     template.JS
+
+	series []*chartSeries
+}
+
+type chartSeries struct {
+	label  string
+	points [][2]float64
+}
+
+func newHTMLDocument() *htmlDocument {
+	return &htmlDocument{}
+}
+
+func (hd *htmlDocument) addAction(name string, r *Report) {
+	hd.names = append(hd.names, name)
+	hd.reports = append(hd.reports, r)
+}
+
+func (c *htmlChart) add(label string, x, y float64) {
+	for _, s := range c.series {
+		if s.label == label {
+			s.points = append(s.points, [2]float64{x, y})
+			return
+		}
+	}
+	c.series = append(c.series, &chartSeries{label: label, points: [][2]float64{{x, y}}})
+}
+
+// close builds the rows of dygraphs from the series, one row per point with
+// NaN in the columns of the other series.
+func (c *htmlChart) close() {
+	c.Labels = []string{"Seconds"}
+	var rows [][]float64
+	for i, s := range c.series {
+		c.Labels = append(c.Labels, s.label)
+		for _, p := range s.points {
+			row := make([]float64, len(c.series)+1)
+			for j := range row {
+				row[j] = math.NaN()
+			}
+			row[0], row[i+1] = p[0], p[1]
+			rows = append(rows, row)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	buf := []byte("[")
+	for i, row := range rows {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '[')
+		for j, v := range row {
+			if j > 0 {
+				buf = append(buf, ',')
+			}
+			if math.IsNaN(v) {
+				buf = append(buf, "NaN"...)
+			} else {
+				buf = strconv.AppendFloat(buf, v, 'f', -1, 64)
+			}
+		}
+		buf = append(buf, ']')
+	}
+	c.Data = template.JS(append(buf, ']'))
+}
+
+// addStep charts the results of the actions of a step from its timeline, and
+// their confirmed transactions and gas per L2 block from their reports.
+func (hd *htmlDocument) addStep(step int, tl *Timeline) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	chartID := func(name string) string { return fmt.Sprintf("step%d-%s", step, name) }
+	rate := &htmlChart{ID: chartID("rate"), Title: "Requests sent and transactions confirmed per second", YLabel: "Per second"}
+	latency := &htmlChart{ID: chartID("latency"), Title: "Latency percentiles", YLabel: "Latency (ms)", LogScale: true}
+	gas := &htmlChart{ID: chartID("gas"), Title: "Gas used per L2 block", YLabel: "Gas"}
+	errs := &htmlChart{ID: chartID("errors"), Title: "Errors", YLabel: "Failed requests"}
+
+	bucket := tl.bucket.Seconds()
+	for _, index := range tl.indexes() {
+		x := float64(index) * bucket
+		for _, action := range tl.actions {
+			b, ok := tl.buckets[index][action]
+			if !ok {
+				continue
+			}
+			rate.add(action+" sent", x, float64(b.requests)/bucket)
+			for _, q := range []float64{0.50, 0.90, 0.99} {
+				latency.add(fmt.Sprintf("%s p%d", action, int(q*100)), x, float64(b.latencies.Quantile(q))/float64(time.Millisecond))
+			}
+			errs.add(action, x, float64(b.requests-b.success))
+		}
+	}
+
+	start := float64(tl.start.UnixNano()) / float64(time.Second)
+	blockGas := make(map[uint64]blockReport)
+	for i, r := range hd.reports {
+		r.mu.Lock()
+		blockTime := float64(1)
+		if r.l2BlockTime != nil && r.l2BlockTime.Sign() > 0 {
+			blockTime = float64(r.l2BlockTime.Uint64())
+		}
+		for _, number := range sortedBlocks(r.l2Blocks) {
+			block := r.l2Blocks[number]
+			x := float64(block.time) - start
+			rate.add(hd.names[i]+" confirmed", x, float64(r.l2TxsByBlock[number])/blockTime)
+			gas.add(hd.names[i], x, float64(r.l2GasUsedByBlock[number]))
+			blockGas[number] = block
+		}
+		r.mu.Unlock()
+	}
+	for _, number := range sortedBlocks(blockGas) {
+		block := blockGas[number]
+		gas.add("block", float64(block.time)-start, float64(block.gasUsed))
+	}
+
+	s := &htmlStep{Step: step}
+	for _, c := range []*htmlChart{rate, latency, gas, errs} {
+		if len(c.series) == 0 {
+			continue
+		}
+		c.close()
+		s.Charts = append(s.Charts, c)
+	}
+	hd.steps = append(hd.steps, s)
+	hd.names, hd.reports = nil, nil
+}
+
+func sortedBlocks(blocks map[uint64]blockReport) []uint64 {
+	numbers := make([]uint64, 0, len(blocks))
+	for number := range blocks {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers
+}
+
+func htmlAsset(path string) ([]byte, error) {
+	file, err := plot.Assets.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (hd *htmlDocument) render(w io.Writer) error {
+	css, err := htmlAsset("dygraph.css")
+	if err != nil {
+		return err
+	}
+	js, err := htmlAsset("dygraph.min.js")
+	if err != nil {
+		return err
+	}
+	return htmlTemplate.Execute(w, struct {
+		DygraphsCSS template.CSS
+		DygraphsJS  template.JS
+		Steps       []*htmlStep
+		Text        string
+	}{
+		DygraphsCSS: template.CSS(css),
+		DygraphsJS:  template.JS(js),
+		Steps:       hd.steps,
+		Text:        hd.text.String(),
+	})
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!doctype html>
+<html>
+<head>
+  <title>tokamak-trunks report</title>
+  <meta charset="utf-8">
+  <style>{{.DygraphsCSS}}</style>
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    .chart { width: 100%; height: 360px; margin-bottom: 3em; }
+    pre { background: #f6f6f6; padding: 1em; overflow-x: auto; }
+  </style>
+</head>
+<body>
+  <h1>tokamak-trunks report</h1>
+  {{range .Steps}}
+  <h2>Step {{.Step}}</h2>
+  {{range .Charts}}<div id="{{.ID}}" class="chart"></div>
+  {{end}}{{end}}
+  <h2>Report</h2>
+  <pre>{{.Text}}</pre>
+  <script>{{.DygraphsJS}}</script>
+  <script>
+  {{range .Steps}}{{range .Charts}}
+  new Dygraph(document.getElementById({{.ID}}), {{.Data}}, {
+    title: {{.Title}},
+    labels: {{.Labels}},
+    xlabel: "Seconds elapsed",
+    ylabel: {{.YLabel}},
+    logscale: {{.LogScale}},
+    connectSeparatedPoints: true,
+    legend: "always",
+    strokeWidth: 1.3
+  });
+  {{end}}{{end}}
+  </script>
+</body>
+</html>
+`))
@@ -0,0 +1,271 @@
+package reporter
+
+import (
+	"math/big"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// jsonDocument is the JSON report of a run. Durations are in nanoseconds
+// like vegeta's JSON reporter, and big integers are decimal strings.
+type jsonDocument struct {
+	Actions   []*jsonAction        `json:"actions"`
+	Timelines []*jsonTimeline      `json:"timelines,omitempty"`
+	FeeVaults []*jsonStepFeeVaults `json:"feeVaults,omitempty"`
+}
+
+type jsonAction struct {
+	Step    int                        `json:"step"`
+	Name    string                     `json:"name"`
+	Metrics *vegeta.Metrics            `json:"metrics"`
+	Methods map[string]*vegeta.Metrics `json:"methods"`
+	Report  *jsonReport                `json:"report"`
+}
+
+type jsonReport struct {
+	TPS                  string `json:"tps"`
+	TotalConfirmedTx     string `json:"totalConfirmedTx"`
+	ReceiptCount         uint64 `json:"receiptCount"`
+	BlobTxCount          uint64 `json:"blobTxCount"`
+	FirstBlockNumber     string `json:"firstConfirmedBlockNumber"`
+	LastBlockNumber      string `json:"lastConfirmedBlockNumber"`
+	L1GasUsed            string `json:"l1GasUsed"`
+	BlobGasUsed          string `json:"blobGasUsed"`
+	L2GasUsed            string `json:"l2GasUsed"`
+	L1GasPrice           string `json:"l1GasPrice"`
+	BlobGasPrice         string `json:"blobGasPrice"`
+	L2GasPrice           string `json:"l2GasPrice"`
+	L1Fee                string `json:"l1Fee"`
+	BlobFee              string `json:"blobFee"`
+	L2Fee                string `json:"l2Fee"`
+	L2BaseFee            string `json:"l2BaseFee"`
+	L2PriorityFee        string `json:"l2PriorityFee"`
+	AverageL2BaseFee     string `json:"averageL2BaseFee"`
+	AverageL2PriorityFee string `json:"averageL2PriorityFee"`
+	L2BlockTime          string `json:"l2BlockTime"`
+
+	InclusionLatencies   map[string]*jsonLatency `json:"inclusionLatencies,omitempty"`
+	InitiatedWithdrawals uint64                  `json:"initiatedWithdrawals,omitempty"`
+	Proposer             *jsonProposer           `json:"proposer,omitempty"`
+	Deployments          *jsonDeployments        `json:"deployments,omitempty"`
+	Batches              *jsonBatches            `json:"batches,omitempty"`
+	Blocks               *jsonBlocks             `json:"blocks,omitempty"`
+}
+
+type jsonLatency struct {
+	Count uint64        `json:"count"`
+	Min   time.Duration `json:"min"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"50th"`
+	P90   time.Duration `json:"90th"`
+	P95   time.Duration `json:"95th"`
+	P99   time.Duration `json:"99th"`
+	Max   time.Duration `json:"max"`
+}
+
+type jsonProposer struct {
+	Outputs uint64 `json:"outputs"`
+	L1Txs   uint64 `json:"l1Txs"`
+	L1Fee   string `json:"l1Fee"`
+}
+
+type jsonDeployments struct {
+	Count        uint64 `json:"count"`
+	CodeSize     uint64 `json:"codeSize"`
+	MinGasUsed   uint64 `json:"minGasUsed"`
+	MeanGasUsed  uint64 `json:"meanGasUsed"`
+	MaxGasUsed   uint64 `json:"maxGasUsed"`
+	TotalGasUsed uint64 `json:"totalGasUsed"`
+}
+
+type jsonBatches struct {
+	Requests uint64            `json:"requests"`
+	Elements uint64            `json:"elements"`
+	Errors   map[string]uint64 `json:"errors,omitempty"`
+}
+
+type jsonBlocks struct {
+	SustainedTPS float64      `json:"sustainedTps"`
+	PeakTPS      float64      `json:"peakTps"`
+	Empty        int          `json:"empty"`
+	Underfilled  int          `json:"underfilled"`
+	Rows         []*jsonBlock `json:"rows"`
+}
+
+type jsonBlock struct {
+	Number   uint64 `json:"number"`
+	Time     uint64 `json:"time"`
+	Interval uint64 `json:"interval"`
+	Txs      uint64 `json:"txs"`
+	Ours     uint64 `json:"ours"`
+	Foreign  uint64 `json:"foreign"`
+	GasUsed  uint64 `json:"gasUsed"`
+	GasLimit uint64 `json:"gasLimit"`
+	BaseFee  string `json:"baseFee"`
+	L1Origin uint64 `json:"l1Origin"`
+}
+
+type jsonStepFeeVaults struct {
+	Step   int             `json:"step"`
+	Vaults []*jsonFeeVault `json:"vaults"`
+}
+
+type jsonFeeVault struct {
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	FromBlock   uint64 `json:"fromBlock"`
+	ToBlock     uint64 `json:"toBlock"`
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	Change      string `json:"change"`
+	ReceiptFees string `json:"receiptFees"`
+	StepFees    string `json:"stepFees"`
+	Mismatch    string `json:"mismatch"`
+}
+
+type jsonTimeline struct {
+	Step    int                `json:"step"`
+	Actions []string           `json:"actions"`
+	Bucket  time.Duration      `json:"bucket"`
+	Buckets []*jsonTimelineRow `json:"buckets"`
+}
+
+type jsonTimelineRow struct {
+	Offset  time.Duration                  `json:"offset"`
+	Actions map[string]*jsonTimelineBucket `json:"actions"`
+}
+
+type jsonTimelineBucket struct {
+	Requests uint64        `json:"requests"`
+	Success  uint64        `json:"success"`
+	Mean     time.Duration `json:"mean"`
+}
+
+func bigString(x *big.Int) string {
+	if x == nil {
+		return "0"
+	}
+	return x.String()
+}
+
+func (r *Report) toJSON() *jsonReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calcGasPrices()
+
+	jr := &jsonReport{
+		TPS:                  bigString(r.tps),
+		TotalConfirmedTx:     bigString(r.totalConfirmTransactions),
+		ReceiptCount:         r.receiptCount,
+		BlobTxCount:          r.blobTxCount,
+		FirstBlockNumber:     bigString(r.startBlockNumber),
+		LastBlockNumber:      bigString(r.endBlockNumber),
+		L1GasUsed:            bigString(r.l1GasUsed),
+		BlobGasUsed:          bigString(r.blobGasUsed),
+		L2GasUsed:            bigString(r.l2GasUsed),
+		L1GasPrice:           bigString(r.l1GasPrice),
+		BlobGasPrice:         bigString(r.blobGasPrice),
+		L2GasPrice:           bigString(r.l2GasPrice),
+		L1Fee:                bigString(r.l1Fee),
+		BlobFee:              bigString(r.blobFee),
+		L2Fee:                bigString(r.l2Fee),
+		L2BaseFee:            bigString(r.l2BaseFee),
+		L2PriorityFee:        bigString(r.l2PriorityFee),
+		AverageL2BaseFee:     bigString(r.averageFee(r.l2BaseFee)),
+		AverageL2PriorityFee: bigString(r.averageFee(r.l2PriorityFee)),
+		L2BlockTime:          bigString(r.l2BlockTime),
+		InitiatedWithdrawals: r.initiatedWithdrawals,
+	}
+
+	if len(r.inclusionLatencies) > 0 {
+		jr.InclusionLatencies = make(map[string]*jsonLatency)
+		for stage, sl := range r.inclusionLatencies {
+			l := sl.latencies
+			jr.InclusionLatencies[stage] = &jsonLatency{
+				Count: sl.count,
+				Min:   l.Min,
+				Mean:  time.Duration(float64(l.Total) / float64(sl.count)),
+				P50:   l.Quantile(0.50),
+				P90:   l.Quantile(0.90),
+				P95:   l.Quantile(0.95),
+				P99:   l.Quantile(0.99),
+				Max:   l.Max,
+			}
+		}
+	}
+	if r.proposer != nil {
+		jr.Proposer = &jsonProposer{
+			Outputs: r.proposer.outputs,
+			L1Txs:   r.proposer.l1Txs,
+			L1Fee:   bigString(r.proposer.l1Fee),
+		}
+	}
+	if d := r.deployments; d.count > 0 {
+		jr.Deployments = &jsonDeployments{
+			Count:        d.count,
+			CodeSize:     d.codeSize,
+			MinGasUsed:   d.minGasUsed,
+			MeanGasUsed:  d.totalGasUsed / d.count,
+			MaxGasUsed:   d.maxGasUsed,
+			TotalGasUsed: d.totalGasUsed,
+		}
+	}
+	if b := r.batches; b.requests > 0 {
+		jr.Batches = &jsonBatches{
+			Requests: b.requests,
+			Elements: b.elements,
+			Errors:   b.errors,
+		}
+	}
+	if len(r.blocks) > 0 {
+		s := r.blockStats()
+		jr.Blocks = &jsonBlocks{
+			SustainedTPS: s.sustainedTPS,
+			PeakTPS:      s.peakTPS,
+			Empty:        s.empty,
+			Underfilled:  s.underfilled,
+		}
+		for _, b := range r.blocks {
+			jr.Blocks.Rows = append(jr.Blocks.Rows, &jsonBlock{
+				Number:   b.Number,
+				Time:     b.Time,
+				Interval: b.Interval,
+				Txs:      b.Txs,
+				Ours:     b.Ours,
+				Foreign:  b.foreign(),
+				GasUsed:  b.GasUsed,
+				GasLimit: b.GasLimit,
+				BaseFee:  bigString(b.BaseFee),
+				L1Origin: b.L1Origin,
+			})
+		}
+	}
+	return jr
+}
+
+func (tl *Timeline) toJSON(step int) *jsonTimeline {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	jt := &jsonTimeline{
+		Step:    step,
+		Actions: tl.actions,
+		Bucket:  tl.bucket,
+	}
+	for _, index := range tl.indexes() {
+		row := &jsonTimelineRow{
+			Offset:  time.Duration(index) * tl.bucket,
+			Actions: make(map[string]*jsonTimelineBucket),
+		}
+		for action, b := range tl.buckets[index] {
+			row.Actions[action] = &jsonTimelineBucket{
+				Requests: b.requests,
+				Success:  b.success,
+				Mean:     b.mean(),
+			}
+		}
+		jt.Buckets = append(jt.Buckets, row)
+	}
+	return jt
+}
@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// MethodMetrics splits the results of an action by JSON-RPC method, so a
+// slow method is not hidden by fast ones in the totals.
+type MethodMetrics struct {
+	metrics map[string]*vegeta.Metrics
+}
+
+func NewMethodMetrics() *MethodMetrics {
+	return &MethodMetrics{
+		metrics: make(map[string]*vegeta.Metrics),
+	}
+}
+
+// RPCMethod returns the JSON-RPC method tagged in the URL fragment of a
+// result, which is not sent to the node, without the request id after it.
+func RPCMethod(r *vegeta.Result) string {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Fragment == "" {
+		return "unknown"
+	}
+	method, _, _ := strings.Cut(u.Fragment, "/")
+	return method
+}
+
+func (mm *MethodMetrics) Add(method string, r *vegeta.Result) {
+	m, ok := mm.metrics[method]
+	if !ok {
+		m = &vegeta.Metrics{}
+		mm.metrics[method] = m
+	}
+	m.Add(r)
+}
+
+func (mm *MethodMetrics) Close() {
+	for _, m := range mm.metrics {
+		m.Close()
+	}
+}
+
+func (mm *MethodMetrics) methods() []string {
+	methods := make([]string, 0, len(mm.metrics))
+	for method := range mm.metrics {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Reporter writes the requests, throughput, latencies and errors of each method.
+func (mm *MethodMetrics) Reporter() vegeta.Reporter {
+	return func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		if _, err := fmt.Fprintf(tw, "Method\tRequests\tRate\tThroughput\tSuccess\tLatencies [min, mean, 50, 90, 95, 99, max]\n"); err != nil {
+			return err
+		}
+		for _, method := range mm.methods() {
+			m := mm.metrics[method]
+			l := m.Latencies
+			if _, err := fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f%%\t%s, %s, %s, %s, %s, %s, %s\n",
+				method, m.Requests, m.Rate, m.Throughput, m.Success*100,
+				l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max,
+			); err != nil {
+				return err
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		for _, method := range mm.methods() {
+			m := mm.metrics[method]
+			if len(m.Errors) == 0 {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "Error Set of %s:\n", method); err != nil {
+				return err
+			}
+			for _, e := range m.Errors {
+				if _, err := fmt.Fprintln(w, e); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
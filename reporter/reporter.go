@@ -2,46 +2,203 @@ package reporter
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
 	"os"
+	"sort"
 	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
 	vegeta "github.com/tsenart/vegeta/v12/lib"
 )
 
 var once sync.Once
 
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+	HTMLFormat = "html"
+)
+
+// reportManager writes the reports of a run to the output file, section by
+// section as text, or as one JSON document or HTML page when it is closed.
 type reportManager struct {
-	w *os.File
+	w              *os.File
+	format         string
+	timelineBucket time.Duration
+
+	summary  *Summary
+	document *jsonDocument
+	html     *htmlDocument
+
+	// raw results are captured when set
+	resultsDir string
+	manifest   *manifest
 }
 
 var reportMgr *reportManager
 
+// Report writes a text section. It is skipped in JSON format, and kept for
+// the end of the page in HTML format.
 func (rm *reportManager) Report(r vegeta.Reporter, title string) error {
-	rm.w.WriteString(title + "\n")
-	err := r.Report(rm.w)
+	var w io.Writer = rm.w
+	switch rm.format {
+	case JSONFormat:
+		return nil
+	case HTMLFormat:
+		w = &rm.html.text
+	}
+	io.WriteString(w, title+"\n")
+	err := r.Report(w)
 	if err != nil {
 		return err
 	}
-	rm.w.WriteString("\n")
+	io.WriteString(w, "\n")
 	return nil
 }
 
-func (rm *reportManager) Close() {
-	reportMgr.w.Close()
+// ReportAction reports the metrics, the metrics by method and the
+// transaction report of a finished action.
+func (rm *reportManager) ReportAction(step int, name string, m *vegeta.Metrics, methods *MethodMetrics, r *Report) error {
+	rm.summary.Add(fmt.Sprintf("%d. %s", step, name), m, r)
+	if rm.format == JSONFormat {
+		rm.document.Actions = append(rm.document.Actions, &jsonAction{
+			Step:    step,
+			Name:    name,
+			Metrics: m,
+			Methods: methods.metrics,
+			Report:  r.toJSON(),
+		})
+		return nil
+	}
+	if rm.format == HTMLFormat {
+		rm.html.addAction(name, r)
+	}
+	if err := rm.Report(vegeta.NewTextReporter(m), name); err != nil {
+		return err
+	}
+	if err := rm.Report(methods.Reporter(), name+" by method"); err != nil {
+		return err
+	}
+	if err := rm.Report(r.Reporter(), "Transaction report of "+name); err != nil {
+		return err
+	}
+	if len(r.blocks) == 0 {
+		return nil
+	}
+	return rm.Report(r.BlocksReporter(), "Blocks of "+name)
+}
+
+// ReportTimeline reports the timeline of the actions of a step after they
+// are reported. It is charted for every step in HTML format, and written for
+// parallel steps only otherwise.
+func (rm *reportManager) ReportTimeline(step int, tl *Timeline) error {
+	if rm.format == HTMLFormat {
+		rm.html.addStep(step, tl)
+	}
+	if len(tl.actions) < 2 {
+		return nil
+	}
+	if rm.format == JSONFormat {
+		rm.document.Timelines = append(rm.document.Timelines, tl.toJSON(step))
+		return nil
+	}
+	return rm.Report(tl.Reporter(), "Timeline")
+}
+
+// Close writes the scenario summary, or the JSON document, or the HTML page,
+// and closes the file.
+func (rm *reportManager) Close() error {
+	defer rm.w.Close()
+	switch rm.format {
+	case JSONFormat:
+		encoder := json.NewEncoder(rm.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rm.document)
+	case HTMLFormat:
+		if err := rm.Report(rm.summary.Reporter(), "Scenario summary"); err != nil {
+			return err
+		}
+		return rm.html.render(rm.w)
+	}
+	return rm.Report(rm.summary.Reporter(), "Scenario summary")
+}
+
+// NewTimeline returns a timeline of the actions with the configured bucket.
+func (rm *reportManager) NewTimeline(actions []string) *Timeline {
+	return NewTimeline(actions, rm.timelineBucket)
 }
 
 func GetReportManager() *reportManager {
 	return reportMgr
 }
 
-type reports struct {
+const (
+	UnsafeStage       = "Unsafe"
+	SafeStage         = "Safe"
+	FinalizedStage    = "Finalized"
+	L1InclusionStage  = "L1Inclusion"
+	L2DerivationStage = "L2Derivation"
+	// until an L2 output covering the block is proposed on L1
+	OutputProposalStage = "OutputProposal"
+)
+
+var inclusionStages = []string{
+	UnsafeStage,
+	SafeStage,
+	FinalizedStage,
+	L1InclusionStage,
+	L2DerivationStage,
+	OutputProposalStage,
+}
+
+type proposerReport struct {
+	outputs uint64
+	l1Txs   uint64
+	l1Fee   *big.Int
+}
+
+type deploymentReport struct {
+	count        uint64
+	codeSize     uint64
+	minGasUsed   uint64
+	maxGasUsed   uint64
+	totalGasUsed uint64
+}
+
+type batchReport struct {
+	requests uint64
+	elements uint64
+	errors   map[string]uint64
+}
+
+type blockReport struct {
+	time    uint64
+	gasUsed uint64
+}
+
+// Observer follows the transactions of an action while it runs.
+type Observer interface {
+	// a transaction is accepted by the node and waits for confirmation
+	TransactionPending()
+	// a pending transaction is confirmed, failed or timed out
+	TransactionSettled()
+	// a transaction is confirmed successfully, on L1 for blob transactions
+	TransactionConfirmed(receipt *types.Receipt)
+}
+
+type stageLatency struct {
+	count     uint64
+	latencies vegeta.LatencyMetrics
+}
+
+// Report holds the transaction statistics of one action.
+type Report struct {
 	tps                      *big.Int
 	totalConfirmTransactions *big.Int
 	l1GasUsed                *big.Int
@@ -50,6 +207,8 @@ type reports struct {
 	blobFee                  *big.Int
 	l1Fee                    *big.Int
 	l2Fee                    *big.Int
+	l2BaseFee                *big.Int
+	l2PriorityFee            *big.Int
 	l1GasPrice               *big.Int
 	blobGasPrice             *big.Int
 	l2GasPrice               *big.Int
@@ -61,40 +220,214 @@ type reports struct {
 	l2BlockTime              *big.Int
 	receiptCount             uint64
 	blobTxCount              uint64
+	// seconds between the first and the last confirmed block, read by RecordTPS
+	tpsSpan uint64
+
+	// gas used by our receipts per L2 block, to split L2 fee by base fee
+	l2GasUsedByBlock map[uint64]uint64
+	l2TxsByBlock     map[uint64]uint64
+	// L2 blocks of our receipts, read by RecordL2FeeBreakdown
+	l2Blocks map[uint64]blockReport
+	// every L2 block between the first and the last confirmed block, read by RecordBlocks
+	blocks []*blockRow
+
+	// latency from sending a transaction until its block is unsafe, safe and finalized
+	inclusionLatencies map[string]*stageLatency
+
+	initiatedWithdrawals uint64
+	proposer             *proposerReport
+	deployments          deploymentReport
+	batches              batchReport
+
+	// no receipt has been recorded yet
+	first bool
+
+	// receipts file of the action when results are captured
+	records *json.Encoder
+	// live telemetry of the action
+	observers []Observer
+
+	mu sync.Mutex
 }
 
-var (
-	trunksReport *reports
-	first        bool
-)
+// HeaderReader reads the L2 headers of the confirmed blocks of a report. It
+// is an ethclient.Client, or the headers of captured results.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+func (r *Report) header(headers HeaderReader, number *big.Int) (*types.Header, error) {
+	header, err := headers.HeaderByNumber(context.Background(), number)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.capture(&record{Kind: headerRecord, Header: header})
+	return header, nil
+}
 
-func (r *reports) RecordTPS(client *ethclient.Client) {
-	startBlock, _ := client.BlockByNumber(context.Background(), r.startBlockNumber)
-	endBlock, _ := client.BlockByNumber(context.Background(), r.endBlockNumber)
+func (r *Report) RecordTPS(headers HeaderReader) error {
+	if r.receiptCount == 0 {
+		return nil
+	}
+	startBlock, err := r.header(headers, r.startBlockNumber)
+	if err != nil {
+		return err
+	}
+	endBlock, err := r.header(headers, r.endBlockNumber)
+	if err != nil {
+		return err
+	}
 
-	d := endBlock.Time() - startBlock.Time()
-	duration := new(big.Int).SetUint64(d)
+	r.tpsSpan = endBlock.Time - startBlock.Time
+	duration := new(big.Int).SetUint64(r.tpsSpan)
 
 	tr := new(big.Int).Set(r.totalConfirmTransactions)
 	if duration.Cmp(big.NewInt(0)) == 0 {
-		r.tps = tr.Div(tr, r.l2BlockTime)
-		return
+		r.tps = tr.Div(tr, r.blockTime())
+		return nil
 	}
 
 	r.tps = tr.Div(tr, duration)
+	return nil
+}
+
+// exactTPS is the TPS of RecordTPS without truncating it to an integer.
+func (r *Report) exactTPS() float64 {
+	span := r.tpsSpan
+	if span == 0 {
+		span = r.blockTime().Uint64()
+	}
+	return bigFloat(r.totalConfirmTransactions) / float64(span)
+}
+
+// RecordL2FeeBreakdown splits the recorded L2 fee into the part burned by
+// base fee and the priority fee, using the base fee of each confirmed block.
+func (r *Report) RecordL2FeeBreakdown(headers HeaderReader) error {
+	r.l2BaseFee.SetUint64(0)
+	for number, gasUsed := range r.l2GasUsedByBlock {
+		header, err := r.header(headers, new(big.Int).SetUint64(number))
+		if err != nil {
+			return err
+		}
+		r.l2Blocks[number] = blockReport{time: header.Time, gasUsed: header.GasUsed}
+		if header.BaseFee == nil {
+			continue
+		}
+		baseFee := new(big.Int).Mul(header.BaseFee, new(big.Int).SetUint64(gasUsed))
+		r.l2BaseFee.Add(r.l2BaseFee, baseFee)
+	}
+	r.l2PriorityFee.Sub(r.l2Fee, r.l2BaseFee)
+	return nil
+}
+
+func (r *Report) RecordInclusionLatency(stage string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sl, ok := r.inclusionLatencies[stage]
+	if !ok {
+		sl = &stageLatency{}
+		r.inclusionLatencies[stage] = sl
+	}
+	sl.count++
+	sl.latencies.Add(latency)
+	r.capture(&record{Kind: latencyRecord, Stage: stage, Latency: latency})
+}
+
+func (r *Report) RecordInitiatedWithdrawal() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.initiatedWithdrawals++
+	r.capture(&record{Kind: withdrawalRecord})
+}
+
+// RecordProposer records the outputs proposed and the L1 transactions and
+// fee spent by the proposer during an action.
+func (r *Report) RecordProposer(outputs, l1Txs uint64, l1Fee *big.Int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.proposer = &proposerReport{
+		outputs: outputs,
+		l1Txs:   l1Txs,
+		l1Fee:   l1Fee,
+	}
+	r.capture(&record{Kind: proposerRecord, Outputs: outputs, L1Txs: l1Txs, L1Fee: l1Fee})
+}
+
+// RecordDeployment records a successful contract creation with the size of
+// the deployed code.
+func (r *Report) RecordDeployment(codeSize int, gasUsed uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := &r.deployments
+	if d.count == 0 || gasUsed < d.minGasUsed {
+		d.minGasUsed = gasUsed
+	}
+	if gasUsed > d.maxGasUsed {
+		d.maxGasUsed = gasUsed
+	}
+	d.count++
+	d.codeSize += uint64(codeSize)
+	d.totalGasUsed += gasUsed
+	r.capture(&record{Kind: deploymentRecord, CodeSize: codeSize, GasUsed: gasUsed})
 }
 
-func (r *reports) RecordConfirmRequest() {
+// RecordBatch records the elements of a JSON-RPC batch response and the
+// errors of the failed elements.
+func (r *Report) RecordBatch(elements int, errs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := &r.batches
+	if b.errors == nil {
+		b.errors = make(map[string]uint64)
+	}
+	b.requests++
+	b.elements += uint64(elements)
+	for _, e := range errs {
+		b.errors[e]++
+	}
+	r.capture(&record{Kind: batchRecord, Elements: elements, Errors: errs})
+}
+
+func (r *Report) RecordConfirmRequest() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.totalConfirmTransactions.Add(r.totalConfirmTransactions, big.NewInt(1))
+	r.capture(&record{Kind: confirmRecord})
+}
+
+// Observe adds an observer of the pending and confirmed transactions.
+func (r *Report) Observe(o Observer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers = append(r.observers, o)
 }
 
-func (r *reports) RecordReceipt(receipt *types.Receipt) {
+// RecordPendingTransaction records a sent transaction waiting for its
+// confirmation, until RecordSettledTransaction.
+func (r *Report) RecordPendingTransaction() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.observers {
+		o.TransactionPending()
+	}
+}
+
+func (r *Report) RecordSettledTransaction() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.observers {
+		o.TransactionSettled()
+	}
+}
+
+func (r *Report) RecordReceipt(receipt *types.Receipt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.receiptCount++
 	if receipt.Type == types.BlobTxType {
-		r.blobTxCount++
-		r.recordBlobGasPrice(receipt)
-		r.recordBlobGasUsed(receipt)
-		r.recordBlobFee(receipt)
+		r.recordBlob(receipt)
 	}
 	r.recordStartToLastBlock(receipt)
 	r.recordL1GasUsed(receipt)
@@ -103,12 +436,46 @@ func (r *reports) RecordReceipt(receipt *types.Receipt) {
 	r.recordL2Fee(receipt)
 	r.recordL1GasPrice(receipt)
 	r.recordL2GasPrice(receipt)
+	r.capture(&record{Kind: receiptRecord, Receipt: receipt})
+	for _, o := range r.observers {
+		o.TransactionConfirmed(receipt)
+	}
+}
+
+// RecordBlobReceipt records the blob gas and fee of an L1 blob transaction,
+// apart from the L2 metrics of RecordReceipt.
+func (r *Report) RecordBlobReceipt(receipt *types.Receipt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordBlob(receipt)
+	r.capture(&record{Kind: blobReceiptRecord, Receipt: receipt})
+	for _, o := range r.observers {
+		o.TransactionConfirmed(receipt)
+	}
 }
 
-func (r *reports) recordStartToLastBlock(receipt *types.Receipt) {
-	if first {
+// RecordDepositReceipt records the receipt of a deposit derived on L2. Its
+// fees are paid on L1, so only the observers follow it.
+func (r *Report) RecordDepositReceipt(receipt *types.Receipt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.observers {
+		o.TransactionConfirmed(receipt)
+	}
+}
+
+func (r *Report) recordBlob(receipt *types.Receipt) {
+	r.blobTxCount++
+	r.recordBlobGasPrice(receipt)
+	r.recordBlobGasUsed(receipt)
+	r.recordBlobFee(receipt)
+}
+
+func (r *Report) recordStartToLastBlock(receipt *types.Receipt) {
+	if r.first {
 		r.startBlockNumber.Set(receipt.BlockNumber)
-		first = false
+		r.endBlockNumber.Set(receipt.BlockNumber)
+		r.first = false
 		return
 	}
 	if r.startBlockNumber.Cmp(receipt.BlockNumber) > 0 {
@@ -119,28 +486,30 @@ func (r *reports) recordStartToLastBlock(receipt *types.Receipt) {
 	}
 }
 
-func (r *reports) recordBlobGasUsed(receipt *types.Receipt) {
+func (r *Report) recordBlobGasUsed(receipt *types.Receipt) {
 	r.blobGasUsed.Add(r.blobGasUsed, new(big.Int).SetUint64(receipt.BlobGasUsed))
 }
 
-func (r *reports) recordBlobFee(receipt *types.Receipt) {
-	blobFee := receipt.BlobGasUsed * receipt.BlobGasPrice.Uint64()
-	r.blobFee.Add(r.blobFee, new(big.Int).SetUint64(blobFee))
+func (r *Report) recordBlobFee(receipt *types.Receipt) {
+	blobFee := new(big.Int).Mul(receipt.BlobGasPrice, new(big.Int).SetUint64(receipt.BlobGasUsed))
+	r.blobFee.Add(r.blobFee, blobFee)
 }
 
-func (r *reports) recordL1GasUsed(receipt *types.Receipt) {
+func (r *Report) recordL1GasUsed(receipt *types.Receipt) {
 	r.l1GasUsed.Add(r.l1GasUsed, receipt.L1GasUsed)
 }
 
-func (r *reports) recordL2GasUsed(receipt *types.Receipt) {
+func (r *Report) recordL2GasUsed(receipt *types.Receipt) {
 	r.l2GasUsed.Add(r.l2GasUsed, new(big.Int).SetUint64(receipt.GasUsed))
+	r.l2GasUsedByBlock[receipt.BlockNumber.Uint64()] += receipt.GasUsed
+	r.l2TxsByBlock[receipt.BlockNumber.Uint64()]++
 }
 
-func (r *reports) recordL1Fee(receipt *types.Receipt) {
+func (r *Report) recordL1Fee(receipt *types.Receipt) {
 	r.l1Fee.Add(r.l1Fee, receipt.L1Fee)
 }
 
-func (r *reports) recordL2Fee(receipt *types.Receipt) {
+func (r *Report) recordL2Fee(receipt *types.Receipt) {
 	l2GasFee := big.NewInt(0)
 	l2GasFee.Mul(
 		receipt.EffectiveGasPrice,
@@ -150,19 +519,19 @@ func (r *reports) recordL2Fee(receipt *types.Receipt) {
 	r.l2Fee.Add(r.l2Fee, l2GasFee)
 }
 
-func (r *reports) recordL1GasPrice(receipt *types.Receipt) {
+func (r *Report) recordL1GasPrice(receipt *types.Receipt) {
 	r.cumulativeL1GasPrice.Add(r.cumulativeL1GasPrice, receipt.L1GasPrice)
 }
 
-func (r *reports) recordBlobGasPrice(receipt *types.Receipt) {
+func (r *Report) recordBlobGasPrice(receipt *types.Receipt) {
 	r.cumulativeBlobGasPrice.Add(r.cumulativeBlobGasPrice, receipt.BlobGasPrice)
 }
 
-func (r *reports) recordL2GasPrice(receipt *types.Receipt) {
+func (r *Report) recordL2GasPrice(receipt *types.Receipt) {
 	r.cumulativeL2GasPrice.Add(r.cumulativeL2GasPrice, receipt.EffectiveGasPrice)
 }
 
-func (r *reports) calcGasPrices() {
+func (r *Report) calcGasPrices() {
 	if r.receiptCount > 0 {
 		r.l1GasPrice.Quo(r.cumulativeL1GasPrice, new(big.Int).SetUint64(r.receiptCount))
 		r.l2GasPrice.Quo(r.cumulativeL2GasPrice, new(big.Int).SetUint64(r.receiptCount))
@@ -183,43 +552,90 @@ func weiToGwei(wei *big.Int) *big.Float {
 	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei))
 }
 
-func GetTrunksReport() *reports {
-	return trunksReport
+// L2 block time in seconds when it is not configured
+const defaultL2BlockTime = 2
+
+func (r *Report) blockTime() *big.Int {
+	if r.l2BlockTime == nil || r.l2BlockTime.Sign() == 0 {
+		return big.NewInt(defaultL2BlockTime)
+	}
+	return r.l2BlockTime
+}
+
+func NewReport(l2BlockTime *big.Int) *Report {
+	return &Report{
+		tps:                      big.NewInt(0),
+		totalConfirmTransactions: big.NewInt(0),
+		l1GasUsed:                big.NewInt(0),
+		l2GasUsed:                big.NewInt(0),
+		blobGasUsed:              big.NewInt(0),
+		blobFee:                  big.NewInt(0),
+		l1Fee:                    big.NewInt(0),
+		l2Fee:                    big.NewInt(0),
+		l2BaseFee:                big.NewInt(0),
+		l2PriorityFee:            big.NewInt(0),
+		l1GasPrice:               big.NewInt(0),
+		blobGasPrice:             big.NewInt(0),
+		l2GasPrice:               big.NewInt(0),
+		cumulativeL1GasPrice:     big.NewInt(0),
+		cumulativeBlobGasPrice:   big.NewInt(0),
+		cumulativeL2GasPrice:     big.NewInt(0),
+		startBlockNumber:         big.NewInt(0),
+		endBlockNumber:           big.NewInt(0),
+		l2BlockTime:              l2BlockTime,
+		l2GasUsedByBlock:         make(map[uint64]uint64),
+		l2TxsByBlock:             make(map[uint64]uint64),
+		l2Blocks:                 make(map[uint64]blockReport),
+		inclusionLatencies:       make(map[string]*stageLatency),
+		first:                    true,
+	}
 }
 
-func InitReporter(cfg *Config) {
+func InitReporter(cfg *Config) error {
+	var err error
 	once.Do(
 		func() {
-			trunksReport = &reports{
-				tps:                      big.NewInt(0),
-				totalConfirmTransactions: big.NewInt(0),
-				l1GasUsed:                big.NewInt(0),
-				l2GasUsed:                big.NewInt(0),
-				blobGasUsed:              big.NewInt(0),
-				blobFee:                  big.NewInt(0),
-				l1Fee:                    big.NewInt(0),
-				l2Fee:                    big.NewInt(0),
-				l1GasPrice:               big.NewInt(0),
-				blobGasPrice:             big.NewInt(0),
-				l2GasPrice:               big.NewInt(0),
-				cumulativeL1GasPrice:     big.NewInt(0),
-				cumulativeBlobGasPrice:   big.NewInt(0),
-				cumulativeL2GasPrice:     big.NewInt(0),
-				startBlockNumber:         big.NewInt(0),
-				endBlockNumber:           big.NewInt(0),
-				l2BlockTime:              cfg.l2BlockTime,
+			switch cfg.format {
+			case "", TextFormat, JSONFormat, HTMLFormat:
+			default:
+				err = fmt.Errorf("wrong report format: %q", cfg.format)
+				return
+			}
+			if cfg.resultsDir != "" {
+				if err = os.MkdirAll(cfg.resultsDir, 0755); err != nil {
+					return
+				}
 			}
 			file, _ := os.Create(cfg.filename)
 			reportMgr = &reportManager{
-				w: file,
+				w:              file,
+				format:         cfg.format,
+				timelineBucket: cfg.timelineBucket,
+				summary:        NewSummary(),
+				document:       &jsonDocument{},
+				html:           newHTMLDocument(),
+				resultsDir:     cfg.resultsDir,
+				manifest: &manifest{
+					Version:     manifestVersion,
+					L2BlockTime: cfg.l2BlockTime.Uint64(),
+				},
 			}
-			first = true
 		},
 	)
+	return err
 }
 
-func (r *reports) report(w io.Writer) error {
+func (r *Report) averageFee(fee *big.Int) *big.Int {
+	if r.receiptCount == 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Quo(fee, new(big.Int).SetUint64(r.receiptCount))
+}
+
+func (r *Report) report(w io.Writer) error {
 	r.calcGasPrices()
+	avgL2BaseFee := r.averageFee(r.l2BaseFee)
+	avgL2PriorityFee := r.averageFee(r.l2PriorityFee)
 
 	const fmtstr = "TPS\t%d\n" +
 		"Total Confirmed Tx\t%d\n" +
@@ -237,6 +653,11 @@ func (r *reports) report(w io.Writer) error {
 		"  L1Fee\t%d Wei (%f ETH)\n" +
 		"  BlobFee\t%d Wei (%f ETH)\n" +
 		"  L2Fee\t%d Wei (%f ETH)\n" +
+		"    BaseFee\t%d Wei (%f ETH)\n" +
+		"    PriorityFee\t%d Wei (%f ETH)\n" +
+		"Average L2 Fee Per Tx\n" +
+		"  BaseFee\t%d Wei (%f Gwei)\n" +
+		"  PriorityFee\t%d Wei (%f Gwei)\n" +
 		"L2BlockTime\t%ds\n"
 	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
 	if _, err := fmt.Fprintf(tw, fmtstr,
@@ -247,15 +668,119 @@ func (r *reports) report(w io.Writer) error {
 		r.l1GasUsed, r.blobGasUsed, r.l2GasUsed,
 		r.l1GasPrice, weiToGwei(r.l1GasPrice), r.blobGasPrice, weiToGwei(r.blobGasPrice), r.l2GasPrice, weiToGwei(r.l2GasPrice),
 		r.l1Fee, weiToEther(r.l1Fee), r.blobFee, weiToEther(r.blobFee), r.l2Fee, weiToEther(r.l2Fee),
+		r.l2BaseFee, weiToEther(r.l2BaseFee), r.l2PriorityFee, weiToEther(r.l2PriorityFee),
+		avgL2BaseFee, weiToGwei(avgL2BaseFee), avgL2PriorityFee, weiToGwei(avgL2PriorityFee),
 		r.l2BlockTime,
 	); err != nil {
 		return err
 	}
+	if err := r.reportBlockStats(tw); err != nil {
+		return err
+	}
+	if err := r.reportInclusionLatencies(tw); err != nil {
+		return err
+	}
+	if err := r.reportWithdrawals(tw); err != nil {
+		return err
+	}
+	if err := r.reportDeployments(tw); err != nil {
+		return err
+	}
+	if err := r.reportBatches(tw); err != nil {
+		return err
+	}
 	return tw.Flush()
 }
 
-func TrunksReporter() vegeta.Reporter {
+func (r *Report) reportWithdrawals(w io.Writer) error {
+	if r.initiatedWithdrawals == 0 && r.proposer == nil {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Initiated Withdrawals\t%d\n", r.initiatedWithdrawals); err != nil {
+		return err
+	}
+	if r.proposer == nil {
+		return nil
+	}
+	const fmtstr = "Proposer\n" +
+		"  Proposed Outputs\t%d\n" +
+		"  L1 Txs\t%d\n" +
+		"  L1 Fee\t%d Wei (%f ETH)\n"
+	_, err := fmt.Fprintf(w, fmtstr,
+		r.proposer.outputs,
+		r.proposer.l1Txs,
+		r.proposer.l1Fee, weiToEther(r.proposer.l1Fee),
+	)
+	return err
+}
+
+func (r *Report) reportBatches(w io.Writer) error {
+	b := r.batches
+	if b.requests == 0 {
+		return nil
+	}
+	var failed uint64
+	for _, count := range b.errors {
+		failed += count
+	}
+	if _, err := fmt.Fprintf(w, "Batch Requests [total, elements, failed elements]\t%d, %d, %d\n", b.requests, b.elements, failed); err != nil {
+		return err
+	}
+	errs := make([]string, 0, len(b.errors))
+	for e := range b.errors {
+		errs = append(errs, e)
+	}
+	sort.Strings(errs)
+	for _, e := range errs {
+		if _, err := fmt.Fprintf(w, "  %s\t%d\n", e, b.errors[e]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Report) reportDeployments(w io.Writer) error {
+	d := r.deployments
+	if d.count == 0 {
+		return nil
+	}
+	const fmtstr = "Contract Deployments\t%d\n" +
+		"  Code Size [total, mean]\t%d, %d bytes\n" +
+		"  Gas Used [min, mean, max]\t%d, %d, %d\n"
+	_, err := fmt.Fprintf(w, fmtstr,
+		d.count,
+		d.codeSize, d.codeSize/d.count,
+		d.minGasUsed, d.totalGasUsed/d.count, d.maxGasUsed,
+	)
+	return err
+}
+
+func (r *Report) reportInclusionLatencies(w io.Writer) error {
+	if len(r.inclusionLatencies) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Inclusion Latencies\t[count, min, mean, 50, 90, 95, 99, max]\n"); err != nil {
+		return err
+	}
+	for _, stage := range inclusionStages {
+		sl, ok := r.inclusionLatencies[stage]
+		if !ok {
+			continue
+		}
+		l := sl.latencies
+		mean := time.Duration(float64(l.Total) / float64(sl.count))
+		if _, err := fmt.Fprintf(w, "  %s\t%d, %s, %s, %s, %s, %s, %s, %s\n",
+			stage, sl.count, l.Min, mean,
+			l.Quantile(0.50), l.Quantile(0.90), l.Quantile(0.95), l.Quantile(0.99), l.Max,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Report) Reporter() vegeta.Reporter {
 	return func(w io.Writer) (err error) {
-		return trunksReport.report(w)
+		return r.report(w)
 	}
 }
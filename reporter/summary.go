@@ -0,0 +1,62 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+type actionSummary struct {
+	name      string
+	requests  uint64
+	success   float64
+	latency   time.Duration
+	confirmed *big.Int
+	tps       *big.Int
+	l2Fee     *big.Int
+}
+
+// Summary lists the main numbers of every action of a scenario.
+type Summary struct {
+	actions []actionSummary
+}
+
+func NewSummary() *Summary {
+	return &Summary{}
+}
+
+// Add records an action after its metrics are closed and its report is complete.
+func (s *Summary) Add(name string, m *vegeta.Metrics, r *Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.actions = append(s.actions, actionSummary{
+		name:      name,
+		requests:  m.Requests,
+		success:   m.Success,
+		latency:   m.Latencies.Mean,
+		confirmed: new(big.Int).Set(r.totalConfirmTransactions),
+		tps:       new(big.Int).Set(r.tps),
+		l2Fee:     new(big.Int).Set(r.l2Fee),
+	})
+}
+
+func (s *Summary) Reporter() vegeta.Reporter {
+	return func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		if _, err := fmt.Fprintf(tw, "Action\tRequests\tSuccess\tMean Latency\tConfirmed Tx\tTPS\tL2Fee\n"); err != nil {
+			return err
+		}
+		for _, a := range s.actions {
+			if _, err := fmt.Fprintf(tw, "%s\t%d\t%.2f%%\t%s\t%d\t%d\t%f ETH\n",
+				a.name, a.requests, a.success*100, a.latency, a.confirmed, a.tps, weiToEther(a.l2Fee),
+			); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,117 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+type timelineBucket struct {
+	requests  uint64
+	success   uint64
+	latencies vegeta.LatencyMetrics
+}
+
+func (b *timelineBucket) mean() time.Duration {
+	return time.Duration(int64(b.latencies.Total) / int64(b.requests))
+}
+
+// Timeline buckets the results of actions running at the same time by
+// time since the start, so that their effect on each other can be seen side
+// by side.
+type Timeline struct {
+	actions []string
+	bucket  time.Duration
+
+	mu      sync.Mutex
+	start   time.Time
+	buckets map[int64]map[string]*timelineBucket
+}
+
+func NewTimeline(actions []string, bucket time.Duration) *Timeline {
+	if bucket <= 0 {
+		bucket = time.Second
+	}
+	return &Timeline{
+		actions: actions,
+		bucket:  bucket,
+		buckets: make(map[int64]map[string]*timelineBucket),
+	}
+}
+
+func (tl *Timeline) Start(start time.Time) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.start = start
+}
+
+func (tl *Timeline) Add(action string, r *vegeta.Result) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	index := int64(r.Timestamp.Sub(tl.start) / tl.bucket)
+	buckets, ok := tl.buckets[index]
+	if !ok {
+		buckets = make(map[string]*timelineBucket)
+		tl.buckets[index] = buckets
+	}
+	b, ok := buckets[action]
+	if !ok {
+		b = &timelineBucket{}
+		buckets[action] = b
+	}
+	b.requests++
+	if r.Code >= 200 && r.Code < 400 && r.Error == "" {
+		b.success++
+	}
+	b.latencies.Add(r.Latency)
+}
+
+// Reporter writes the requests, successes and mean latency of each action
+// per bucket since the start.
+func (tl *Timeline) Reporter() vegeta.Reporter {
+	return func(w io.Writer) error {
+		tl.mu.Lock()
+		defer tl.mu.Unlock()
+
+		indexes := tl.indexes()
+
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		header := []string{"Time"}
+		for _, action := range tl.actions {
+			header = append(header, action+" [requests, success, mean]")
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(header, "\t")); err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			row := []string{(time.Duration(index) * tl.bucket).String()}
+			for _, action := range tl.actions {
+				b, ok := tl.buckets[index][action]
+				if !ok {
+					row = append(row, "-")
+					continue
+				}
+				row = append(row, fmt.Sprintf("%d, %d, %s", b.requests, b.success, b.mean()))
+			}
+			if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
+
+func (tl *Timeline) indexes() []int64 {
+	indexes := make([]int64, 0, len(tl.buckets))
+	for index := range tl.buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
@@ -0,0 +1,226 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+const (
+	baseFeeVault   = "BaseFeeVault"
+	sequencerVault = "SequencerFeeVault"
+	l1FeeVault     = "L1FeeVault"
+)
+
+// FeeVaults are the addresses of the vaults receiving the fees of L2
+// transactions: the base fee, the priority fee and the L1 fee.
+type FeeVaults struct {
+	BaseFee   common.Address
+	Sequencer common.Address
+	L1Fee     common.Address
+}
+
+// FeeVaultReader reads the balances of the fee vaults and the receipts of
+// the L2 blocks. ethclient.Client is a FeeVaultReader.
+type FeeVaultReader interface {
+	HeaderReader
+	BlockNumber(ctx context.Context) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+}
+
+// feeVault is the balance of a fee vault before and after the blocks of a
+// step, and the fees paid to it by the receipts of these blocks.
+type feeVault struct {
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+	From    uint64         `json:"from"`
+	To      uint64         `json:"to"`
+	Before  *big.Int       `json:"before"`
+	After   *big.Int       `json:"after"`
+	Fees    *big.Int       `json:"fees"`
+}
+
+func (v *feeVault) change() *big.Int {
+	return new(big.Int).Sub(v.After, v.Before)
+}
+
+// mismatch is the balance change not paid by the receipts, which is zero
+// unless fees are not paid as the receipts say or the vault is withdrawn.
+func (v *feeVault) mismatch() *big.Int {
+	return new(big.Int).Sub(v.change(), v.Fees)
+}
+
+// VaultBalances follows the fee vaults over the L2 blocks of a step.
+type VaultBalances struct {
+	from   uint64
+	vaults []*feeVault
+}
+
+// ReadVaultBalances reads the balances of the fee vaults at the latest L2
+// block, before the actions of a step start.
+func ReadVaultBalances(reader FeeVaultReader, vaults FeeVaults) (*VaultBalances, error) {
+	from, err := reader.BlockNumber(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	vb := &VaultBalances{from: from}
+	for _, v := range []struct {
+		name    string
+		address common.Address
+	}{
+		{baseFeeVault, vaults.BaseFee},
+		{sequencerVault, vaults.Sequencer},
+		{l1FeeVault, vaults.L1Fee},
+	} {
+		balance, err := reader.BalanceAt(context.Background(), v.address, new(big.Int).SetUint64(from))
+		if err != nil {
+			return nil, fmt.Errorf("balance of %s: %w", v.name, err)
+		}
+		vb.vaults = append(vb.vaults, &feeVault{
+			Name:    v.name,
+			Address: v.address,
+			From:    from,
+			Before:  balance,
+			Fees:    big.NewInt(0),
+		})
+	}
+	return vb, nil
+}
+
+// Close reads the balances of the fee vaults at the latest L2 block, after
+// the actions of the step are done, and sums the fees paid to each vault by
+// every receipt of the blocks in between.
+func (vb *VaultBalances) Close(reader FeeVaultReader) error {
+	to, err := reader.BlockNumber(context.Background())
+	if err != nil {
+		return err
+	}
+	base, sequencer, l1 := vb.vaults[0], vb.vaults[1], vb.vaults[2]
+	for number := vb.from + 1; number <= to; number++ {
+		header, err := reader.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+		if err != nil {
+			return err
+		}
+		receipts, err := reader.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		if err != nil {
+			return err
+		}
+		for _, receipt := range receipts {
+			// deposits are paid on L1
+			if receipt.Type == types.DepositTxType {
+				continue
+			}
+			gasUsed := new(big.Int).SetUint64(receipt.GasUsed)
+			fee := new(big.Int).Mul(receipt.EffectiveGasPrice, gasUsed)
+			if header.BaseFee != nil {
+				baseFee := new(big.Int).Mul(header.BaseFee, gasUsed)
+				base.Fees.Add(base.Fees, baseFee)
+				fee.Sub(fee, baseFee)
+			}
+			sequencer.Fees.Add(sequencer.Fees, fee)
+			if receipt.L1Fee != nil {
+				l1.Fees.Add(l1.Fees, receipt.L1Fee)
+			}
+		}
+	}
+	for _, v := range vb.vaults {
+		balance, err := reader.BalanceAt(context.Background(), v.Address, new(big.Int).SetUint64(to))
+		if err != nil {
+			return fmt.Errorf("balance of %s: %w", v.Name, err)
+		}
+		v.To = to
+		v.After = balance
+	}
+	return nil
+}
+
+// ReportFeeVaults reports the fee vaults of a step once, after its actions
+// are reported, since parallel actions share the blocks of the step. The
+// vaults are kept in the manifest when results are captured.
+func (rm *reportManager) ReportFeeVaults(step int, vb *VaultBalances, reports []*Report) error {
+	if rm.resultsDir != "" {
+		for _, s := range rm.manifest.Steps {
+			if s.Step == step {
+				s.FeeVaults = vb.vaults
+			}
+		}
+		if err := rm.writeManifest(); err != nil {
+			return err
+		}
+	}
+	return rm.reportFeeVaults(step, vb.vaults, reports)
+}
+
+func (rm *reportManager) reportFeeVaults(step int, vaults []*feeVault, reports []*Report) error {
+	if len(vaults) == 0 {
+		return nil
+	}
+	if rm.format == JSONFormat {
+		sv := &jsonStepFeeVaults{Step: step}
+		for _, v := range vaults {
+			sv.Vaults = append(sv.Vaults, &jsonFeeVault{
+				Name:        v.Name,
+				Address:     v.Address.Hex(),
+				FromBlock:   v.From + 1,
+				ToBlock:     v.To,
+				Before:      bigString(v.Before),
+				After:       bigString(v.After),
+				Change:      bigString(v.change()),
+				ReceiptFees: bigString(v.Fees),
+				StepFees:    bigString(stepFees(v, reports)),
+				Mismatch:    bigString(v.mismatch()),
+			})
+		}
+		rm.document.FeeVaults = append(rm.document.FeeVaults, sv)
+		return nil
+	}
+	return rm.Report(feeVaultsReporter(vaults, reports), "Fee Vaults")
+}
+
+// stepFees are the fees paid to the vault by the receipts of the actions of
+// the step.
+func stepFees(v *feeVault, reports []*Report) *big.Int {
+	fees := big.NewInt(0)
+	for _, r := range reports {
+		switch v.Name {
+		case baseFeeVault:
+			fees.Add(fees, r.l2BaseFee)
+		case sequencerVault:
+			fees.Add(fees, r.l2PriorityFee)
+		case l1FeeVault:
+			fees.Add(fees, r.l1Fee)
+		}
+	}
+	return fees
+}
+
+func feeVaultsReporter(vaults []*feeVault, reports []*Report) vegeta.Reporter {
+	return func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		v := vaults[0]
+		if _, err := fmt.Fprintf(tw, "Blocks %d to %d\t[balance change, receipts fees, step fees, mismatch]\n", v.From+1, v.To); err != nil {
+			return err
+		}
+		for _, v := range vaults {
+			mismatch := v.mismatch()
+			verdict := ""
+			if mismatch.Sign() != 0 {
+				verdict = " MISMATCH"
+			}
+			if _, err := fmt.Fprintf(tw, "  %s\t%d, %d, %d, %d Wei%s\n",
+				v.Name, v.change(), v.Fees, stepFees(v, reports), mismatch, verdict,
+			); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
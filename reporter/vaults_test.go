@@ -0,0 +1,153 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestFeeVaultMismatch(t *testing.T) {
+	tests := []struct {
+		name                string
+		before, after, fees int64
+		mismatch            int64
+	}{
+		{name: "reconciled", before: 100, after: 150, fees: 50},
+		{name: "no fees", before: 100, after: 100},
+		{name: "unpaid fees", before: 100, after: 130, fees: 50, mismatch: -20},
+		{name: "withdrawn", before: 100, after: 0, fees: 50, mismatch: -150},
+		{name: "foreign deposit", before: 100, after: 200, fees: 50, mismatch: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &feeVault{
+				Before: big.NewInt(tt.before),
+				After:  big.NewInt(tt.after),
+				Fees:   big.NewInt(tt.fees),
+			}
+			if got := v.mismatch(); got.Cmp(big.NewInt(tt.mismatch)) != 0 {
+				t.Errorf("mismatch = %s, want %d", got, tt.mismatch)
+			}
+		})
+	}
+}
+
+// fakeFeeVaultReader serves one L2 block after the step starts, and the
+// balances of the vaults by block.
+type fakeFeeVaultReader struct {
+	blockNumbers []uint64
+	header       *types.Header
+	receipts     []*types.Receipt
+	balances     map[uint64]*big.Int
+}
+
+func (f *fakeFeeVaultReader) BlockNumber(_ context.Context) (uint64, error) {
+	number := f.blockNumbers[0]
+	f.blockNumbers = f.blockNumbers[1:]
+	return number, nil
+}
+
+func (f *fakeFeeVaultReader) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if number.Cmp(f.header.Number) != 0 {
+		return nil, fmt.Errorf("header %s not found", number)
+	}
+	return f.header, nil
+}
+
+func (f *fakeFeeVaultReader) BlockReceipts(_ context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	if number, _ := blockNrOrHash.Number(); number.Int64() != f.header.Number.Int64() {
+		return nil, fmt.Errorf("receipts of %s not found", blockNrOrHash.String())
+	}
+	return f.receipts, nil
+}
+
+func (f *fakeFeeVaultReader) BalanceAt(_ context.Context, _ common.Address, number *big.Int) (*big.Int, error) {
+	return f.balances[number.Uint64()], nil
+}
+
+func TestVaultBalancesClose(t *testing.T) {
+	transfer := &types.Receipt{
+		Type:              types.DynamicFeeTxType,
+		GasUsed:           21000,
+		EffectiveGasPrice: big.NewInt(150),
+		L1Fee:             big.NewInt(7),
+	}
+	legacy := &types.Receipt{
+		Type:              types.LegacyTxType,
+		GasUsed:           30000,
+		EffectiveGasPrice: big.NewInt(100),
+		L1Fee:             big.NewInt(5),
+	}
+	deposit := &types.Receipt{
+		Type:              types.DepositTxType,
+		GasUsed:           50000,
+		EffectiveGasPrice: big.NewInt(1000),
+	}
+
+	tests := []struct {
+		name     string
+		baseFee  *big.Int
+		receipts []*types.Receipt
+		// fees of the base fee, sequencer and L1 fee vaults
+		base, sequencer, l1 int64
+	}{
+		{name: "empty block", baseFee: big.NewInt(100)},
+		{
+			name:     "base and priority split",
+			baseFee:  big.NewInt(100),
+			receipts: []*types.Receipt{transfer},
+			base:     100 * 21000, sequencer: 50 * 21000, l1: 7,
+		},
+		{
+			name:     "deposit skipped",
+			baseFee:  big.NewInt(100),
+			receipts: []*types.Receipt{deposit, transfer},
+			base:     100 * 21000, sequencer: 50 * 21000, l1: 7,
+		},
+		{
+			name:     "l1 fees summed",
+			baseFee:  big.NewInt(100),
+			receipts: []*types.Receipt{transfer, legacy},
+			base:     100 * (21000 + 30000), sequencer: 50 * 21000, l1: 7 + 5,
+		},
+		{
+			name:      "no base fee",
+			receipts:  []*types.Receipt{transfer},
+			sequencer: 150 * 21000, l1: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &fakeFeeVaultReader{
+				blockNumbers: []uint64{10, 11},
+				header:       &types.Header{Number: big.NewInt(11), BaseFee: tt.baseFee},
+				receipts:     tt.receipts,
+				balances:     map[uint64]*big.Int{10: big.NewInt(1000), 11: big.NewInt(1500)},
+			}
+			vb, err := ReadVaultBalances(reader, FeeVaults{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vb.Close(reader); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range []int64{tt.base, tt.sequencer, tt.l1} {
+				v := vb.vaults[i]
+				if v.Fees.Cmp(big.NewInt(want)) != 0 {
+					t.Errorf("%s fees = %s, want %d", v.Name, v.Fees, want)
+				}
+				if v.From != 10 || v.To != 11 {
+					t.Errorf("%s blocks = %d to %d, want 10 to 11", v.Name, v.From, v.To)
+				}
+				if v.change().Cmp(big.NewInt(500)) != 0 {
+					t.Errorf("%s balance change = %s, want 500", v.Name, v.change())
+				}
+			}
+		})
+	}
+}
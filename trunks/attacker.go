@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strings"
 	"sync"
 	"time"
@@ -13,78 +14,187 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	vegeta "github.com/tsenart/vegeta/v12/lib"
 
+	"github.com/tokamak-network/tokamak-trunks/account"
 	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
 )
 
+// how long a sent transaction is waited for until it is included in a block
+const confirmTimeout = 120 * time.Second
+
 type Attacker interface {
 	Attack() <-chan *vegeta.Result
 }
 
 type CallAttacker struct {
-	Pace     vegeta.Pacer
-	Duration time.Duration
-	Targeter vegeta.Targeter
+	Report    *reporter.Report
+	Pace      vegeta.Pacer
+	Duration  time.Duration
+	Targeter  vegeta.Targeter
+	BatchSize int
 }
 type TransactionAttacker struct {
-	Client   *ethclient.Client
-	Pace     vegeta.Pacer
-	Duration time.Duration
-	Targeter vegeta.Targeter
+	Report  *reporter.Report
+	Client  *ethclient.Client
+	Tracker *ConfirmTracker
+	Safety  *HeadTracker
+	// how long Safety waits for the confirmed transactions to be finalized
+	SafetyTimeout time.Duration
+	Nonces        *account.NonceManager
+	Senders       *SenderTracker
+	Pace          vegeta.Pacer
+	Duration      time.Duration
+	Targeter      vegeta.Targeter
+
+	queue txQueue
+	// onConfirm is called with every successful receipt
+	onConfirm func(receipt *types.Receipt)
 }
 
-func MakeAttacker(action *Action, t *Trunks) (Attacker, error) {
+func MakeAttacker(action *Action, t *Trunks, report *reporter.Report) (Attacker, error) {
 	duration, err := time.ParseDuration(action.Duration)
 	if err != nil {
 		return nil, err
 	}
 	if action.Method == "call" {
-		tOption := &TargetOption{
-			RPC: t.L2RPC,
-		}
-		return &CallAttacker{
-			Pace:     action.GetPace(),
-			Duration: duration,
-			Targeter: CallTargeter(tOption),
-		}, nil
-
+		return makeCallAttacker(action, t, report, duration)
 	}
 	if action.Method == "transaction" {
-		rpc := t.L2RPC
-		chainId := t.L2ChainId
-		client, _ := ethclient.Dial(rpc)
-		tOption := &TargetOption{
-			RPC: rpc,
-			TransactionOption: &TransactionOption{
-				Accounts: t.Accounts,
-				ChainId:  chainId,
-				To:       action.To,
-				Client:   client,
-			},
-		}
-		return &TransactionAttacker{
-			Client:   client,
-			Pace:     action.GetPace(),
-			Duration: duration,
-			Targeter: TransactionTargeter(tOption),
-		}, nil
+		return makeTransactionAttacker(action, t, report, duration, nil, nil)
+	}
+	if action.Method == "erc20" {
+		return makeERC20Attacker(action, t, report, duration)
+	}
+	if action.Method == "contract" {
+		return makeContractAttacker(action, t, report, duration)
+	}
+	if action.Method == "deploy" {
+		return makeDeployAttacker(action, t, report, duration)
+	}
+	if action.Method == "blob" {
+		return makeBlobAttacker(action, t, report, duration)
+	}
+	if action.Method == "subscribe" {
+		return makeSubscribeAttacker(action, t, report, duration)
+	}
+	if action.Method == "deposit" {
+		return makeDepositAttacker(action, t, report, duration)
+	}
+	if action.Method == "withdrawal" {
+		return makeWithdrawalAttacker(action, t, report, duration)
 	}
 
 	return nil, fmt.Errorf("wrong action method")
 }
 
+// makeTransactionAttacker sends L2 transactions built by payload, or value
+// transfers between the test accounts when payload is nil.
+func makeTransactionAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration, payload PayloadFunc, txValue *big.Int) (*TransactionAttacker, error) {
+	if err := action.validateTxType(); err != nil {
+		return nil, err
+	}
+	rpc := t.L2RPC
+	chainId := t.L2ChainId
+	client, _ := ethclient.Dial(rpc)
+	nonces, err := t.nonceManager(rpc, client)
+	if err != nil {
+		return nil, err
+	}
+	senders := NewSenderTracker()
+	tracker, err := t.newL2ConfirmTracker()
+	if err != nil {
+		return nil, err
+	}
+	tOption := &TargetOption{
+		RPC: rpc,
+		TransactionOption: &TransactionOption{
+			Accounts: t.Accounts,
+			ChainId:  chainId,
+			To:       action.To,
+			Client:   client,
+			GasLimit: action.GasLimit,
+			TxType:   action.TxType,
+			Fee:      action.Fee,
+			FeeTTL:   t.blockTime(),
+			Nonces:   nonces,
+			Senders:  senders,
+			Value:    txValue,
+			Payload:  payload,
+		},
+	}
+	attacker := &TransactionAttacker{
+		Report:   report,
+		Client:   client,
+		Tracker:  tracker,
+		Nonces:   nonces,
+		Senders:  senders,
+		Pace:     action.GetPace(),
+		Duration: duration,
+		Targeter: TransactionTargeter(tOption),
+	}
+	if action.SafetyTimeout != "" {
+		safetyTimeout, err := time.ParseDuration(action.SafetyTimeout)
+		if err != nil {
+			return nil, err
+		}
+		attacker.Safety = NewSafetyTracker(client, report)
+		attacker.SafetyTimeout = safetyTimeout
+	}
+	if action.Presign != nil {
+		count := action.Presign.Count
+		if count == 0 {
+			hits, err := expectedHits(attacker.Pace, duration)
+			if err != nil {
+				return nil, err
+			}
+			count = (hits + len(t.Accounts.List) - 1) / len(t.Accounts.List)
+		}
+		queue, err := presignTransactions(context.Background(), tOption.TransactionOption, action.Presign, count)
+		if err != nil {
+			return nil, err
+		}
+		attacker.queue = queue
+		attacker.Targeter = PresignedTargeter(tOption, queue)
+	}
+	return attacker, nil
+}
+
+func makeCallAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*CallAttacker, error) {
+	client, err := ethclient.Dial(t.L2RPC)
+	if err != nil {
+		return nil, err
+	}
+	calls := action.Calls
+	if len(calls) == 0 {
+		calls = defaultCalls()
+	}
+	schedule, err := newCallSchedule(calls, newHeadCache(client, t.blockTime()), t.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	tOption := &TargetOption{
+		RPC: t.L2RPC,
+	}
+	return &CallAttacker{
+		Report:    report,
+		Pace:      action.GetPace(),
+		Duration:  duration,
+		Targeter:  CallTargeter(tOption, schedule, action.BatchSize),
+		BatchSize: action.BatchSize,
+	}, nil
+}
+
 func (ca *CallAttacker) Attack() <-chan *vegeta.Result {
-	fmt.Println("call attack start")
+	utils.Printf("call attack start\n")
 	attacker := vegeta.NewAttacker()
 	results := make(chan *vegeta.Result)
-	attackCount := 0
 	var wg sync.WaitGroup
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for res := range attacker.Attack(ca.Targeter, ca.Pace, ca.Duration, "call") {
-			attackCount++
-			fmt.Printf("\rAttack count: %d", attackCount)
+			callError(res, ca.BatchSize, ca.Report)
 			results <- res
 		}
 	}()
@@ -98,84 +208,114 @@ func (ca *CallAttacker) Attack() <-chan *vegeta.Result {
 }
 
 func (ta *TransactionAttacker) Attack() <-chan *vegeta.Result {
-	fmt.Println("transaction attack start")
-	attacker := vegeta.NewAttacker()
+	utils.Printf("transaction attack start\n")
 	results := make(chan *vegeta.Result)
-	reporter := reporter.GetTrunksReport()
-	attackCount := 0
-	var wg sync.WaitGroup
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for res := range attacker.Attack(ta.Targeter, ta.Pace, ta.Duration, "transaction attack") {
-			attackCount++
-			fmt.Printf("\rAttack count: %d", attackCount)
-
-			txHash, jsonErr := txHashFromResult(res)
-			if jsonErr != nil {
-				res.Error = fmt.Sprintf("err: %s", jsonErr.Message)
-				res.Code = uint16(jsonErr.Code)
-				results <- res
-				continue
-			}
+	tReport := ta.Report
 
-			wg.Add(1)
-			go func(txHash common.Hash, result *vegeta.Result) {
-				defer wg.Done()
-				ctx, cancel := context.WithTimeout(context.Background(), time.Second*120)
-				defer cancel()
-				receipt, err := waitTxConfirm(ctx, ta.Client, txHash)
-				receiptTime := result.Timestamp.Add(time.Since(result.Timestamp))
-				latency := receiptTime.Sub(result.Timestamp)
-				result.Latency += latency
-
-				if err != nil {
-					result.Error = err.Error()
-					result.Code = 0
-				}
-				if receipt != nil {
-					switch receipt.Status {
-					case 1:
-						reporter.RecordReceipt(receipt)
-						reporter.RecordConfirmRequest()
-					case 0:
-						result.Error = "transaction confirmed faiure"
-						result.Code = 0
-					}
-				}
-
-				results <- result
-			}(txHash, res)
-		}
-		fmt.Println()
-	}()
+	if err := ta.Tracker.Start(context.Background()); err != nil {
+		utils.Printf("failed to start confirm tracker: %s\n", err)
+		close(results)
+		return results
+	}
+	if ta.Safety != nil {
+		ta.Safety.Start()
+	}
 
+	loop := &confirmLoop{
+		attack:       "transaction attack",
+		report:       tReport,
+		tracker:      ta.Tracker,
+		nonces:       ta.Nonces,
+		senders:      ta.Senders,
+		timeoutError: context.DeadlineExceeded.Error(),
+		failedError:  "transaction confirmed faiure",
+		confirmed: func(c confirmation, result *vegeta.Result) error {
+			tReport.RecordInclusionLatency(reporter.UnsafeStage, result.Latency)
+			if ta.Safety != nil {
+				ta.Safety.Track(c.receipt.BlockNumber.Uint64(), result.Timestamp)
+			}
+			tReport.RecordReceipt(c.receipt)
+			tReport.RecordConfirmRequest()
+			if ta.onConfirm != nil {
+				ta.onConfirm(c.receipt)
+			}
+			return nil
+		},
+	}
 	go func() {
-		wg.Wait()
 		defer close(results)
+		loop.run(ta.Targeter, ta.Pace, ta.Duration, results)
+		ta.Tracker.Stop()
+		if ta.Safety != nil {
+			ta.Safety.Wait(ta.SafetyTimeout)
+		}
+		if ta.queue != nil {
+			ta.queue.Close()
+		}
 	}()
 
 	return results
 }
 
-func waitTxConfirm(
-	ctx context.Context,
-	client *ethclient.Client,
-	txHash common.Hash,
-) (*types.Receipt, error) {
-	queryTicker := time.NewTicker(12 * time.Second)
-	defer queryTicker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-queryTicker.C:
-			if receiept, _ := client.TransactionReceipt(ctx, txHash); receiept != nil {
-				return receiept, nil
-			}
+// confirmLoop sends the transactions of an attack and settles each of them
+// with its receipt found by tracker, or after confirmTimeout. The attacker
+// hooks into the successful receipts with confirmed.
+type confirmLoop struct {
+	attack  string
+	report  *reporter.Report
+	tracker *ConfirmTracker
+	nonces  *account.NonceManager
+	senders *SenderTracker
+	// errors of a transaction not included and of a failed receipt
+	timeoutError string
+	failedError  string
+	// confirmed is called with every successful receipt, and fails the
+	// result when it returns an error
+	confirmed func(c confirmation, result *vegeta.Result) error
+}
+
+// run attacks until duration, and returns when every sent transaction is settled.
+func (cl *confirmLoop) run(targeter vegeta.Targeter, pacer vegeta.Pacer, duration time.Duration, results chan<- *vegeta.Result) {
+	attacker := vegeta.NewAttacker()
+	var wg sync.WaitGroup
+	for res := range attacker.Attack(targeter, pacer, duration, cl.attack) {
+		txHash, ok := sentTxHash(res, cl.senders, cl.nonces)
+		if !ok {
+			results <- res
+			continue
 		}
+
+		confirmCh := cl.tracker.Track(txHash)
+		cl.report.RecordPendingTransaction()
+		wg.Add(1)
+		go func(txHash common.Hash, result *vegeta.Result) {
+			defer wg.Done()
+			defer cl.report.RecordSettledTransaction()
+			defer func() { results <- result }()
+
+			var c confirmation
+			select {
+			case c = <-confirmCh:
+			case <-time.After(confirmTimeout):
+				cl.tracker.Forget(txHash)
+				result.Latency = time.Since(result.Timestamp)
+				result.Error = cl.timeoutError
+				result.Code = 0
+				return
+			}
+			result.Latency = c.confirmedAt.Sub(result.Timestamp)
+			if c.receipt.Status != types.ReceiptStatusSuccessful {
+				result.Error = cl.failedError
+				result.Code = 0
+				return
+			}
+			if err := cl.confirmed(c, result); err != nil {
+				result.Error = err.Error()
+				result.Code = 0
+			}
+		}(txHash, res)
 	}
+	wg.Wait()
 }
 
 type jsonrpcMessage struct {
@@ -193,6 +333,31 @@ type jsonError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// sentTxHash returns the hash of the transaction sent by res. When the
+// request failed it fills the error of res, resynchronizes the nonce of the
+// sender on nonce and transport errors and returns false.
+func sentTxHash(res *vegeta.Result, senders *SenderTracker, nonces *account.NonceManager) (common.Hash, bool) {
+	from, tracked := senders.pop(requestID(res))
+	if res.Error != "" && len(res.Body) == 0 {
+		// the node may not have received the transaction
+		if tracked {
+			nonces.Resync(context.Background(), from)
+		}
+		return common.Hash{}, false
+	}
+
+	txHash, jsonErr := txHashFromResult(res)
+	if jsonErr != nil {
+		if tracked && account.IsNonceError(jsonErr.Message) {
+			nonces.Resync(context.Background(), from)
+		}
+		res.Error = fmt.Sprintf("err: %s", jsonErr.Message)
+		res.Code = uint16(jsonErr.Code)
+		return common.Hash{}, false
+	}
+	return txHash, true
+}
+
 func txHashFromResult(r *vegeta.Result) (common.Hash, *jsonError) {
 	message := jsonrpcMessage{}
 	json.Unmarshal(r.Body, &message)
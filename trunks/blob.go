@@ -0,0 +1,241 @@
+package trunks
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/ethclient"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const (
+	// blobs per transaction allowed by a block
+	maxBlobsPerTx = 6
+	// bytes of a field element carrying data; the first byte is left zero so
+	// every element is below the BLS modulus
+	blobElementData = 31
+	blobCapacity    = blobElementData * 4096
+	// gas limit of a blob transaction without calldata
+	blobTxGasLimit = 21000
+)
+
+type Blob struct {
+	// blobs per transaction, 1 by default
+	Count int `yaml:"count,omitempty"`
+	// payload file split into blobs in order, random payloads if omitted
+	File string `yaml:"file,omitempty"`
+}
+
+type BlobAttacker struct {
+	Report   *reporter.Report
+	Client   *ethclient.Client
+	Tracker  *ConfirmTracker
+	Nonces   *account.NonceManager
+	Senders  *SenderTracker
+	Pace     vegeta.Pacer
+	Duration time.Duration
+	Targeter vegeta.Targeter
+
+	queue txQueue
+}
+
+// makeBlobAttacker sends blob transactions between the test accounts on L1,
+// competing with the batcher for blob space.
+func makeBlobAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*BlobAttacker, error) {
+	if action.TxType != "" {
+		return nil, fmt.Errorf("blob action does not take txType")
+	}
+	if err := action.Fee.validate(); err != nil {
+		return nil, err
+	}
+	blob := action.Blob
+	if blob == nil {
+		blob = &Blob{}
+	}
+	sidecar, err := blobSidecar(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := ethclient.Dial(t.L1RPC)
+	if err != nil {
+		return nil, err
+	}
+	nonces, err := t.nonceManager(t.L1RPC, client)
+	if err != nil {
+		return nil, err
+	}
+	senders := NewSenderTracker()
+
+	gasLimit := action.GasLimit
+	if gasLimit == 0 {
+		gasLimit = blobTxGasLimit
+	}
+	tOption := &TargetOption{
+		RPC: t.L1RPC,
+		TransactionOption: &TransactionOption{
+			Accounts: t.Accounts,
+			ChainId:  t.L1ChainId,
+			To:       action.To,
+			Client:   client,
+			GasLimit: gasLimit,
+			TxType:   BlobTxType,
+			Fee:      action.Fee,
+			FeeTTL:   l1BlockTime,
+			Nonces:   nonces,
+			Senders:  senders,
+			Value:    big.NewInt(0),
+			Sidecar:  sidecar,
+		},
+	}
+	attacker := &BlobAttacker{
+		Report:   report,
+		Client:   client,
+		Tracker:  NewConfirmTracker(client, false),
+		Nonces:   nonces,
+		Senders:  senders,
+		Pace:     action.GetPace(),
+		Duration: duration,
+		Targeter: TransactionTargeter(tOption),
+	}
+	if action.Presign != nil {
+		count := action.Presign.Count
+		if count == 0 {
+			hits, err := expectedHits(attacker.Pace, duration)
+			if err != nil {
+				return nil, err
+			}
+			count = (hits + len(t.Accounts.List) - 1) / len(t.Accounts.List)
+		}
+		queue, err := presignTransactions(context.Background(), tOption.TransactionOption, action.Presign, count)
+		if err != nil {
+			return nil, err
+		}
+		attacker.queue = queue
+		attacker.Targeter = PresignedTargeter(tOption, queue)
+	}
+	return attacker, nil
+}
+
+// blobSidecar returns a SidecarFunc filling blob.Count blobs with random
+// bytes, or with the next chunks of blob.File, wrapping around at its end.
+func blobSidecar(blob *Blob) (SidecarFunc, error) {
+	count := blob.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxBlobsPerTx {
+		return nil, fmt.Errorf("blob count must be between 1 and %d", maxBlobsPerTx)
+	}
+
+	var next func() ([]byte, error)
+	if blob.File == "" {
+		next = func() ([]byte, error) {
+			payload := make([]byte, blobCapacity)
+			_, err := rand.Read(payload)
+			return payload, err
+		}
+	} else {
+		file, err := os.ReadFile(utils.ConvertToAbsPath(blob.File))
+		if err != nil {
+			return nil, err
+		}
+		if len(file) == 0 {
+			return nil, fmt.Errorf("blob file %s is empty", blob.File)
+		}
+		var mu sync.Mutex
+		offset := 0
+		next = func() ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			end := offset + blobCapacity
+			if end > len(file) {
+				end = len(file)
+			}
+			payload := file[offset:end]
+			offset = end % len(file)
+			return payload, nil
+		}
+	}
+
+	return func() (*types.BlobTxSidecar, error) {
+		sidecar := &types.BlobTxSidecar{}
+		for i := 0; i < count; i++ {
+			payload, err := next()
+			if err != nil {
+				return nil, err
+			}
+			b := encodeBlob(payload)
+			commitment, err := kzg4844.BlobToCommitment(b)
+			if err != nil {
+				return nil, err
+			}
+			proof, err := kzg4844.ComputeBlobProof(b, commitment)
+			if err != nil {
+				return nil, err
+			}
+			sidecar.Blobs = append(sidecar.Blobs, b)
+			sidecar.Commitments = append(sidecar.Commitments, commitment)
+			sidecar.Proofs = append(sidecar.Proofs, proof)
+		}
+		return sidecar, nil
+	}, nil
+}
+
+// encodeBlob packs payload into the low 31 bytes of each field element.
+func encodeBlob(payload []byte) kzg4844.Blob {
+	var b kzg4844.Blob
+	for i := 0; len(payload) > 0; i++ {
+		n := copy(b[i*32+1:(i+1)*32], payload)
+		payload = payload[n:]
+	}
+	return b
+}
+
+func (ba *BlobAttacker) Attack() <-chan *vegeta.Result {
+	utils.Printf("blob attack start\n")
+	results := make(chan *vegeta.Result)
+	tReport := ba.Report
+
+	if err := ba.Tracker.Start(context.Background()); err != nil {
+		utils.Printf("failed to start L1 confirm tracker: %s\n", err)
+		close(results)
+		return results
+	}
+
+	loop := &confirmLoop{
+		attack:       "blob attack",
+		report:       tReport,
+		tracker:      ba.Tracker,
+		nonces:       ba.Nonces,
+		senders:      ba.Senders,
+		timeoutError: "blob transaction not included on L1",
+		failedError:  "blob transaction confirmed failure",
+		confirmed: func(c confirmation, result *vegeta.Result) error {
+			tReport.RecordInclusionLatency(reporter.L1InclusionStage, result.Latency)
+			tReport.RecordBlobReceipt(c.receipt)
+			return nil
+		},
+	}
+	go func() {
+		defer close(results)
+		loop.run(ba.Targeter, ba.Pace, ba.Duration, results)
+		ba.Tracker.Stop()
+		if ba.queue != nil {
+			ba.queue.Close()
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,335 @@
+package trunks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+)
+
+// RPCCall is a JSON-RPC request of the call action. String params are
+// templates evaluated for each request with these functions:
+//
+//   - randomAccount: address of a random test account
+//   - head: latest block number
+//   - blockBefore N: head - N
+//   - recentBlock N: random block number in [head - N, head]
+//
+// e.g. eth_getLogs over 10-block windows:
+//
+//	params: [{fromBlock: "{{blockBefore 10}}", toBlock: "{{head}}"}]
+type RPCCall struct {
+	Method string        `yaml:"method"`
+	Weight int           `yaml:"weight,omitempty"`
+	Params []interface{} `yaml:"params,omitempty"`
+}
+
+// paramFunc renders the params of one request.
+type paramFunc func() (interface{}, error)
+
+type callTemplate struct {
+	method string
+	params []paramFunc
+}
+
+// defaultCalls are sent in round robin when the call action has no calls.
+func defaultCalls() []RPCCall {
+	calls := make([]RPCCall, len(CALL_METHOD))
+	for i, method := range CALL_METHOD {
+		calls[i] = RPCCall{Method: method}
+	}
+	return calls
+}
+
+// newCallSchedule repeats each call by its weight, so that a round robin
+// over the schedule sends calls in proportion to their weights.
+func newCallSchedule(calls []RPCCall, heads *headCache, accounts *account.Accounts) ([]*callTemplate, error) {
+	funcs := template.FuncMap{
+		"randomAccount": func() (string, error) {
+			if accounts == nil || len(accounts.List) == 0 {
+				return "", fmt.Errorf("no test accounts")
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(accounts.List))))
+			if err != nil {
+				return "", err
+			}
+			return accounts.List[n.Int64()].Address.Hex(), nil
+		},
+		"head": func() (string, error) {
+			head, err := heads.get()
+			return hexutil.EncodeUint64(head), err
+		},
+		"blockBefore": func(n uint64) (string, error) {
+			head, err := heads.get()
+			return hexutil.EncodeUint64(subFloor(head, n)), err
+		},
+		"recentBlock": func(n uint64) (string, error) {
+			head, err := heads.get()
+			if err != nil {
+				return "", err
+			}
+			from := subFloor(head, n)
+			offset, err := rand.Int(rand.Reader, new(big.Int).SetUint64(head-from+1))
+			if err != nil {
+				return "", err
+			}
+			return hexutil.EncodeUint64(from + offset.Uint64()), nil
+		},
+	}
+
+	var schedule []*callTemplate
+	for _, call := range calls {
+		if call.Method == "" {
+			return nil, fmt.Errorf("call needs method")
+		}
+		if call.Weight < 0 {
+			return nil, fmt.Errorf("weight of %s must not be negative", call.Method)
+		}
+		ct := &callTemplate{method: call.Method}
+		for i, param := range call.Params {
+			p, err := compileParam(param, funcs)
+			if err != nil {
+				return nil, fmt.Errorf("param %d of %s: %w", i, call.Method, err)
+			}
+			ct.params = append(ct.params, p)
+		}
+		weight := call.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		for i := 0; i < weight; i++ {
+			schedule = append(schedule, ct)
+		}
+	}
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("call action needs calls")
+	}
+	return schedule, nil
+}
+
+// compileParam parses the templates in a param decoded from the scenario.
+func compileParam(param interface{}, funcs template.FuncMap) (paramFunc, error) {
+	switch p := param.(type) {
+	case string:
+		if !strings.Contains(p, "{{") {
+			return func() (interface{}, error) { return p, nil }, nil
+		}
+		tmpl, err := template.New("").Funcs(funcs).Parse(p)
+		if err != nil {
+			return nil, err
+		}
+		return func() (interface{}, error) {
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, nil); err != nil {
+				return nil, err
+			}
+			return sb.String(), nil
+		}, nil
+	case []interface{}:
+		elems := make([]paramFunc, len(p))
+		for i, e := range p {
+			var err error
+			if elems[i], err = compileParam(e, funcs); err != nil {
+				return nil, err
+			}
+		}
+		return func() (interface{}, error) {
+			list := make([]interface{}, len(elems))
+			for i, elem := range elems {
+				v, err := elem()
+				if err != nil {
+					return nil, err
+				}
+				list[i] = v
+			}
+			return list, nil
+		}, nil
+	case map[string]interface{}:
+		fields := make(map[string]paramFunc, len(p))
+		for k, v := range p {
+			var err error
+			if fields[k], err = compileParam(v, funcs); err != nil {
+				return nil, err
+			}
+		}
+		return func() (interface{}, error) {
+			object := make(map[string]interface{}, len(fields))
+			for k, field := range fields {
+				v, err := field()
+				if err != nil {
+					return nil, err
+				}
+				object[k] = v
+			}
+			return object, nil
+		}, nil
+	}
+	return func() (interface{}, error) { return param, nil }, nil
+}
+
+// body renders a JSON-RPC request of the call.
+func (ct *callTemplate) body(id uint64) ([]byte, error) {
+	params := make([]interface{}, len(ct.params))
+	for i, p := range ct.params {
+		v, err := p()
+		if err != nil {
+			return nil, err
+		}
+		params[i] = v
+	}
+	return json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  ct.method,
+		"params":  params,
+		"id":      id,
+	})
+}
+
+func subFloor(x, y uint64) uint64 {
+	if x < y {
+		return 0
+	}
+	return x - y
+}
+
+// headCache caches the latest block number for one block time, so that
+// block templates do not query the node for every request.
+type headCache struct {
+	client *ethclient.Client
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	updatedAt time.Time
+	head      uint64
+}
+
+func newHeadCache(client *ethclient.Client, ttl time.Duration) *headCache {
+	return &headCache{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func (hc *headCache) get() (uint64, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.updatedAt.IsZero() || time.Since(hc.updatedAt) > hc.ttl {
+		head, err := hc.client.BlockNumber(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		hc.head = head
+		hc.updatedAt = time.Now()
+	}
+	return hc.head, nil
+}
+
+// batchMethod tags a JSON-RPC batch request with the methods of its calls,
+// sorted and without duplicates so that the tags stay few.
+func batchMethod(calls []*callTemplate) string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, call := range calls {
+		if !seen[call.method] {
+			seen[call.method] = true
+			methods = append(methods, call.method)
+		}
+	}
+	sort.Strings(methods)
+	return "batch:" + strings.Join(methods, "+")
+}
+
+// rpcMethodURL tags the URL of a target with the JSON-RPC method as its
+// fragment, which is not sent to the node but kept in vegeta.Result.URL.
+func rpcMethodURL(rpc, method string) string {
+	return rpc + "#" + method
+}
+
+// rpcRequestURL tags the URL with the JSON-RPC id of the request after the
+// method, so that a result without response body is traced back to it.
+func rpcRequestURL(rpc, method string, id uint64) string {
+	return fmt.Sprintf("%s#%s/%d", rpc, method, id)
+}
+
+// requestID returns the JSON-RPC id tagged by rpcRequestURL, or 0.
+func requestID(r *vegeta.Result) uint64 {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return 0
+	}
+	_, id, _ := strings.Cut(u.Fragment, "/")
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// callError fills the error of a JSON-RPC error response, which is sent
+// with HTTP status 200. For a batch response the errors of the elements are
+// counted, and the result fails with the first error if any element failed.
+func callError(r *vegeta.Result, batchSize int, report *reporter.Report) {
+	if r.Error != "" || len(r.Body) == 0 {
+		return
+	}
+	body := bytes.TrimSpace(r.Body)
+	if len(body) > 0 && body[0] == '[' {
+		batchError(r, body, batchSize, report)
+		return
+	}
+	message := jsonrpcMessage{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return
+	}
+	if message.Error != nil {
+		r.Error = fmt.Sprintf("err: %s", message.Error.Message)
+		r.Code = uint16(message.Error.Code)
+	}
+}
+
+func batchError(r *vegeta.Result, body []byte, batchSize int, report *reporter.Report) {
+	var messages []jsonrpcMessage
+	if err := json.Unmarshal(body, &messages); err != nil {
+		r.Error = fmt.Sprintf("invalid batch response: %s", err)
+		r.Code = 0
+		return
+	}
+	var errs []string
+	for _, message := range messages {
+		if message.Error != nil {
+			errs = append(errs, fmt.Sprintf("err: %s", message.Error.Message))
+			if len(errs) == 1 {
+				r.Error = errs[0]
+				r.Code = uint16(message.Error.Code)
+			}
+		}
+	}
+	for i := len(messages); i < batchSize; i++ {
+		errs = append(errs, "err: missing batch response")
+		if len(errs) == 1 {
+			r.Error = errs[0]
+			r.Code = 0
+		}
+	}
+	elements := len(messages)
+	if elements < batchSize {
+		elements = batchSize
+	}
+	report.RecordBatch(elements, errs)
+}
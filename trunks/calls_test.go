@@ -0,0 +1,113 @@
+package trunks
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+)
+
+func TestBatchError(t *testing.T) {
+	serverError, methodNotFound := -32000, methodNotFoundCode
+
+	tests := []struct {
+		name      string
+		body      string
+		batchSize int
+		// error and code of the result, which starts with code 200
+		err  string
+		code uint16
+	}{
+		{
+			name:      "all succeeded",
+			body:      `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"result":"0x2"}]`,
+			batchSize: 2,
+			code:      200,
+		},
+		{
+			name:      "one failed",
+			body:      `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"boom"}}]`,
+			batchSize: 2,
+			err:       "err: boom",
+			code:      uint16(serverError),
+		},
+		{
+			name:      "first error wins",
+			body:      `[{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"first"}},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"second"}}]`,
+			batchSize: 2,
+			err:       "err: first",
+			code:      uint16(methodNotFound),
+		},
+		{
+			name:      "missing elements",
+			body:      `[{"jsonrpc":"2.0","id":1,"result":"0x1"}]`,
+			batchSize: 3,
+			err:       "err: missing batch response",
+		},
+		{
+			name:      "empty batch",
+			body:      `[]`,
+			batchSize: 1,
+			err:       "err: missing batch response",
+		},
+		{
+			name:      "error before missing elements",
+			body:      `[{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"boom"}}]`,
+			batchSize: 2,
+			err:       "err: boom",
+			code:      uint16(serverError),
+		},
+		{
+			name:      "more elements than batch size",
+			body:      `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"result":"0x2"}]`,
+			batchSize: 1,
+			code:      200,
+		},
+		{
+			name:      "malformed",
+			body:      `[{"jsonrpc":"2.0",`,
+			batchSize: 2,
+			err:       "invalid batch response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &vegeta.Result{Code: 200}
+			batchError(r, []byte(tt.body), tt.batchSize, reporter.NewReport(nil))
+			if !strings.HasPrefix(r.Error, tt.err) || (tt.err == "" && r.Error != "") {
+				t.Errorf("error = %q, want %q", r.Error, tt.err)
+			}
+			if r.Code != tt.code {
+				t.Errorf("code = %d, want %d", r.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want uint64
+	}{
+		{name: "tagged", url: rpcRequestURL("http://localhost:9545", "eth_sendRawTransaction", 42), want: 42},
+		{name: "batch", url: rpcRequestURL("http://localhost:9545", "batch:eth_call+eth_getBalance", 7), want: 7},
+		{name: "max", url: rpcRequestURL("http://localhost:9545", "eth_call", math.MaxUint64), want: math.MaxUint64},
+		{name: "method only", url: rpcMethodURL("http://localhost:9545", "eth_call")},
+		{name: "no fragment", url: "http://localhost:9545"},
+		{name: "not a number", url: "http://localhost:9545#eth_call/abc"},
+		{name: "negative", url: "http://localhost:9545#eth_call/-1"},
+		{name: "overflow", url: "http://localhost:9545#eth_call/18446744073709551616"},
+		{name: "malformed url", url: "http://localhost:9545/%zz#eth_call/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestID(&vegeta.Result{URL: tt.url}); got != tt.want {
+				t.Errorf("requestID(%q) = %d, want %d", tt.url, got, tt.want)
+			}
+		})
+	}
+}
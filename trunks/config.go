@@ -4,6 +4,7 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/tokamak-network/tokamak-trunks/cmd/flags"
+	"github.com/tokamak-network/tokamak-trunks/metrics"
 	"github.com/tokamak-network/tokamak-trunks/nmgr"
 	"github.com/tokamak-network/tokamak-trunks/reporter"
 )
@@ -12,7 +13,9 @@ type CLIConfig struct {
 	NodeManagerEnable   bool
 	L1RPC               string
 	L2RPC               string
+	L2WS                string
 	ScenarioFilePath    string
+	L1AddressesFilePath string
 	L1ChainId           uint64
 	L2ChainId           uint64
 	L2BlockTime         uint64
@@ -21,19 +24,35 @@ type CLIConfig struct {
 	L2ToL1MessagePasser string
 	Batcher             string
 	Proposer            string
+	BaseFeeVault        string
 	SequencerFeeVault   string
+	L1FeeVault          string
+	Dashboard           bool
 
 	NodeMgr  nmgr.CLIConfig
 	Reporter reporter.CLIConfig
+	Metrics  metrics.CLIConfig
 }
 
 func NewCLIConfig(ctx *cli.Context) *CLIConfig {
+	reporterConfig := reporter.ReadCLIConfig(ctx)
 	return &CLIConfig{
-		L1RPC:            ctx.String(flags.L1RPCFlag.Name),
-		L2RPC:            ctx.String(flags.L2RPCFlag.Name),
-		ScenarioFilePath: ctx.Path(flags.ScenarioFileFlag.Name),
-		L1ChainId:        ctx.Uint64(flags.L1ChainIdFlag.Name),
-		L2ChainId:        ctx.Uint64(flags.L2ChainIdFlag.Name),
-		Reporter:         reporter.ReadCLIConfig(ctx),
+		L1RPC:               ctx.String(flags.L1RPCFlag.Name),
+		L2RPC:               ctx.String(flags.L2RPCFlag.Name),
+		L2WS:                ctx.String(flags.L2WSFlag.Name),
+		ScenarioFilePath:    ctx.Path(flags.ScenarioFileFlag.Name),
+		L1AddressesFilePath: ctx.Path(flags.L1AddressesFileFlag.Name),
+		L1StandardBrige:     ctx.String(flags.L1StandardBridgeFlag.Name),
+		L2ToL1MessagePasser: ctx.String(flags.L2ToL1MessagePasserFlag.Name),
+		Proposer:            ctx.String(flags.ProposerFlag.Name),
+		BaseFeeVault:        ctx.String(flags.BaseFeeVaultFlag.Name),
+		SequencerFeeVault:   ctx.String(flags.SequencerFeeVaultFlag.Name),
+		L1FeeVault:          ctx.String(flags.L1FeeVaultFlag.Name),
+		Dashboard:           ctx.Bool(flags.DashboardFlag.Name),
+		L1ChainId:           ctx.Uint64(flags.L1ChainIdFlag.Name),
+		L2ChainId:           ctx.Uint64(flags.L2ChainIdFlag.Name),
+		L2BlockTime:         reporterConfig.L2BlockTime,
+		Reporter:            reporterConfig,
+		Metrics:             metrics.ReadCLIConfig(ctx),
 	}
 }
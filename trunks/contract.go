@@ -0,0 +1,273 @@
+package trunks
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const (
+	ConstantArg      = "constant"
+	RandomUintArg    = "randomUint"
+	RandomAccountArg = "randomAccount"
+	SequenceArg      = "sequence"
+	RandomBytesArg   = "randomBytes"
+)
+
+type ContractCall struct {
+	Address  string `yaml:"address"`
+	ABI      string `yaml:"abi"`
+	Function string `yaml:"function"`
+	Args     []Arg  `yaml:"args,omitempty"`
+	// wei sent with each call
+	Value string `yaml:"value,omitempty"`
+}
+
+// Arg generates an argument of the called function for each request.
+//
+//   - constant: Value
+//   - randomUint: random integer in [Min, Max]
+//   - randomAccount: address of a random test account
+//   - sequence: Start, Start+1, ...
+//   - randomBytes: Length random bytes
+type Arg struct {
+	Type   string `yaml:"type"`
+	Value  string `yaml:"value,omitempty"`
+	Min    string `yaml:"min,omitempty"`
+	Max    string `yaml:"max,omitempty"`
+	Start  uint64 `yaml:"start,omitempty"`
+	Length int    `yaml:"length,omitempty"`
+}
+
+type argGenerator func() (interface{}, error)
+
+func makeContractAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*TransactionAttacker, error) {
+	if action.Contract == nil {
+		return nil, fmt.Errorf("contract action needs contract")
+	}
+	payload, err := contractCallPayload(action.Contract, t.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	txValue := big.NewInt(0)
+	if action.Contract.Value != "" {
+		var ok bool
+		if txValue, ok = new(big.Int).SetString(action.Contract.Value, 10); !ok {
+			return nil, fmt.Errorf("invalid contract call value: %q", action.Contract.Value)
+		}
+	}
+	return makeTransactionAttacker(action, t, report, duration, payload, txValue)
+}
+
+func contractCallPayload(call *ContractCall, accounts *account.Accounts) (PayloadFunc, error) {
+	if !common.IsHexAddress(call.Address) {
+		return nil, fmt.Errorf("invalid contract address: %q", call.Address)
+	}
+	contractABI, err := loadABI(call.ABI)
+	if err != nil {
+		return nil, err
+	}
+	method, err := findMethod(contractABI, call.Function)
+	if err != nil {
+		return nil, err
+	}
+	if len(method.Inputs) != len(call.Args) {
+		return nil, fmt.Errorf("%s needs %d args, got %d", method.Sig, len(method.Inputs), len(call.Args))
+	}
+
+	generators := make([]argGenerator, len(call.Args))
+	for i, arg := range call.Args {
+		generators[i], err = newArgGenerator(arg, method.Inputs[i].Type, accounts)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d of %s: %w", i, method.Sig, err)
+		}
+	}
+
+	to := common.HexToAddress(call.Address)
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		args := make([]interface{}, len(generators))
+		for i, generate := range generators {
+			arg, err := generate()
+			if err != nil {
+				return nil, nil, err
+			}
+			args[i] = arg
+		}
+		data, err := contractABI.Pack(method.Name, args...)
+		return &to, data, err
+	}, nil
+}
+
+// loadABI reads an ABI file, or the abi field of a compiled artifact JSON.
+func loadABI(path string) (abi.ABI, error) {
+	file, err := os.ReadFile(utils.ConvertToAbsPath(path))
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(file, &artifact); err == nil && len(artifact.ABI) > 0 {
+		file = artifact.ABI
+	}
+	return abi.JSON(bytes.NewReader(file))
+}
+
+// findMethod finds a method by its signature, e.g. "transfer(address,uint256)",
+// or by its name when it is not overloaded.
+func findMethod(contractABI abi.ABI, function string) (abi.Method, error) {
+	for _, method := range contractABI.Methods {
+		if method.Sig == function {
+			return method, nil
+		}
+	}
+	if method, ok := contractABI.Methods[function]; ok {
+		return method, nil
+	}
+	return abi.Method{}, fmt.Errorf("no function %q in abi", function)
+}
+
+func newArgGenerator(arg Arg, t abi.Type, accounts *account.Accounts) (argGenerator, error) {
+	switch arg.Type {
+	case ConstantArg:
+		value, err := parseArg(arg.Value, t)
+		if err != nil {
+			return nil, err
+		}
+		return func() (interface{}, error) {
+			return value, nil
+		}, nil
+	case RandomUintArg:
+		lower, ok := new(big.Int).SetString(arg.Min, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid min: %q", arg.Min)
+		}
+		upper, ok := new(big.Int).SetString(arg.Max, 10)
+		if !ok || upper.Cmp(lower) < 0 {
+			return nil, fmt.Errorf("invalid max: %q", arg.Max)
+		}
+		width := new(big.Int).Add(new(big.Int).Sub(upper, lower), big.NewInt(1))
+		return func() (interface{}, error) {
+			n, err := rand.Int(rand.Reader, width)
+			if err != nil {
+				return nil, err
+			}
+			return convertInt(n.Add(n, lower), t)
+		}, nil
+	case RandomAccountArg:
+		if t.T != abi.AddressTy {
+			return nil, fmt.Errorf("%s is not address", t)
+		}
+		return func() (interface{}, error) {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(accounts.List))))
+			if err != nil {
+				return nil, err
+			}
+			return accounts.List[n.Int64()].Address, nil
+		}, nil
+	case SequenceArg:
+		counter := arg.Start
+		return func() (interface{}, error) {
+			n := atomic.AddUint64(&counter, 1) - 1
+			return convertInt(new(big.Int).SetUint64(n), t)
+		}, nil
+	case RandomBytesArg:
+		length := arg.Length
+		if t.T == abi.FixedBytesTy {
+			length = t.Size
+		}
+		return func() (interface{}, error) {
+			b := make([]byte, length)
+			if _, err := rand.Read(b); err != nil {
+				return nil, err
+			}
+			return convertBytes(b, t)
+		}, nil
+	}
+	return nil, fmt.Errorf("wrong arg type: %q", arg.Type)
+}
+
+// parseArg converts a scenario string to the Go value abi.Pack expects for t.
+func parseArg(s string, t abi.Type) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: %q", t, s)
+		}
+		return convertInt(n, t)
+	case abi.BoolTy:
+		switch s {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid bool: %q", s)
+	case abi.StringTy:
+		return s, nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address: %q", s)
+		}
+		return common.HexToAddress(s), nil
+	case abi.BytesTy, abi.FixedBytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		return convertBytes(b, t)
+	}
+	return nil, fmt.Errorf("unsupported arg type: %s", t)
+}
+
+func convertInt(n *big.Int, t abi.Type) (interface{}, error) {
+	if t.T != abi.IntTy && t.T != abi.UintTy {
+		return nil, fmt.Errorf("%s is not integer", t)
+	}
+	// the range of the type, [0, 2^size) or [-2^(size-1), 2^(size-1))
+	lo, hi := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(t.Size))
+	if t.T == abi.IntTy {
+		hi.Rsh(hi, 1)
+		lo.Neg(hi)
+	}
+	if n.Cmp(lo) < 0 || n.Cmp(hi) >= 0 {
+		return nil, fmt.Errorf("%s is out of range of %s", n, t)
+	}
+	if t.Size > 64 {
+		return n, nil
+	}
+	if t.T == abi.UintTy {
+		return reflect.ValueOf(n.Uint64()).Convert(t.GetType()).Interface(), nil
+	}
+	return reflect.ValueOf(n.Int64()).Convert(t.GetType()).Interface(), nil
+}
+
+func convertBytes(b []byte, t abi.Type) (interface{}, error) {
+	switch t.T {
+	case abi.BytesTy:
+		return b, nil
+	case abi.FixedBytesTy:
+		if len(b) > t.Size {
+			return nil, fmt.Errorf("%d bytes is too long for %s", len(b), t)
+		}
+		array := reflect.New(t.GetType()).Elem()
+		reflect.Copy(array, reflect.ValueOf(b))
+		return array.Interface(), nil
+	}
+	return nil, fmt.Errorf("%s is not bytes", t)
+}
@@ -0,0 +1,198 @@
+package trunks
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+)
+
+func mustType(t *testing.T, s string) abi.Type {
+	t.Helper()
+	typ, err := abi.NewType(s, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typ
+}
+
+func bigPow2(n uint, delta int64) *big.Int {
+	x := new(big.Int).Lsh(big.NewInt(1), n)
+	return x.Add(x, big.NewInt(delta))
+}
+
+func TestConvertInt(t *testing.T) {
+	tests := []struct {
+		name string
+		n    *big.Int
+		typ  string
+		want interface{}
+		// substring of the error, if any
+		err string
+	}{
+		{name: "uint8 zero", n: big.NewInt(0), typ: "uint8", want: uint8(0)},
+		{name: "uint8 max", n: big.NewInt(255), typ: "uint8", want: uint8(255)},
+		{name: "uint8 overflow", n: big.NewInt(256), typ: "uint8", err: "out of range"},
+		{name: "uint8 negative", n: big.NewInt(-1), typ: "uint8", err: "out of range"},
+		{name: "int8 min", n: big.NewInt(-128), typ: "int8", want: int8(-128)},
+		{name: "int8 max", n: big.NewInt(127), typ: "int8", want: int8(127)},
+		{name: "int8 overflow", n: big.NewInt(128), typ: "int8", err: "out of range"},
+		{name: "int8 underflow", n: big.NewInt(-129), typ: "int8", err: "out of range"},
+		{name: "uint64 max", n: bigPow2(64, -1), typ: "uint64", want: uint64(1<<64 - 1)},
+		{name: "uint64 overflow", n: bigPow2(64, 0), typ: "uint64", err: "out of range"},
+		{name: "int64 min", n: new(big.Int).Neg(bigPow2(63, 0)), typ: "int64", want: int64(-1 << 63)},
+		{name: "uint72 as big", n: bigPow2(64, 0), typ: "uint72", want: bigPow2(64, 0)},
+		{name: "uint256 max", n: bigPow2(256, -1), typ: "uint256", want: bigPow2(256, -1)},
+		{name: "uint256 overflow", n: bigPow2(256, 0), typ: "uint256", err: "out of range"},
+		{name: "int256 min", n: new(big.Int).Neg(bigPow2(255, 0)), typ: "int256", want: new(big.Int).Neg(bigPow2(255, 0))},
+		{name: "int256 overflow", n: bigPow2(255, 0), typ: "int256", err: "out of range"},
+		{name: "not integer", n: big.NewInt(1), typ: "address", err: "is not integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertInt(tt.n, mustType(t, tt.typ))
+			checkArg(t, got, err, tt.want, tt.err)
+		})
+	}
+}
+
+func TestParseArg(t *testing.T) {
+	var word [32]byte
+	word[0] = 0x01
+
+	tests := []struct {
+		name string
+		s    string
+		typ  string
+		want interface{}
+		err  string
+	}{
+		{name: "decimal", s: "42", typ: "uint256", want: big.NewInt(42)},
+		{name: "hex", s: "0x10", typ: "uint16", want: uint16(16)},
+		{name: "negative", s: "-5", typ: "int32", want: int32(-5)},
+		{name: "negative uint", s: "-5", typ: "uint32", err: "out of range"},
+		{name: "malformed int", s: "12abc", typ: "uint256", err: "invalid uint256"},
+		{name: "empty int", s: "", typ: "uint256", err: "invalid uint256"},
+		{name: "int overflow", s: "256", typ: "uint8", err: "out of range"},
+		{name: "true", s: "true", typ: "bool", want: true},
+		{name: "false", s: "false", typ: "bool", want: false},
+		{name: "malformed bool", s: "yes", typ: "bool", err: "invalid bool"},
+		{name: "string", s: "hello", typ: "string", want: "hello"},
+		{name: "address", s: "0x00000000000000000000000000000000000000a1", typ: "address", want: common.HexToAddress("0xa1")},
+		{name: "short address", s: "0xa1", typ: "address", err: "invalid address"},
+		{name: "bytes", s: "0x0102", typ: "bytes", want: []byte{1, 2}},
+		{name: "empty bytes", s: "0x", typ: "bytes", want: []byte{}},
+		{name: "bytes without prefix", s: "0102", typ: "bytes", err: "0x prefix"},
+		{name: "malformed bytes", s: "0xzz", typ: "bytes", err: "invalid hex"},
+		{name: "bytes32 padded", s: "0x01", typ: "bytes32", want: word},
+		{name: "bytes4 too long", s: "0x0102030405", typ: "bytes4", err: "too long"},
+		{name: "unsupported", s: "[1]", typ: "uint256[]", err: "unsupported arg type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArg(tt.s, mustType(t, tt.typ))
+			checkArg(t, got, err, tt.want, tt.err)
+		})
+	}
+}
+
+func TestNewArgGenerator(t *testing.T) {
+	accounts := &account.Accounts{List: []account.Account{
+		{Address: common.HexToAddress("0xa1")},
+		{Address: common.HexToAddress("0xb0")},
+	}}
+	isAccount := func(v interface{}) error {
+		if v != accounts.List[0].Address && v != accounts.List[1].Address {
+			return fmt.Errorf("%v is not a test account", v)
+		}
+		return nil
+	}
+	hasLength := func(n int) func(v interface{}) error {
+		return func(v interface{}) error {
+			if l := reflect.ValueOf(v).Len(); l != n {
+				return fmt.Errorf("length = %d, want %d", l, n)
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name string
+		arg  Arg
+		typ  string
+		// substring of the error of newArgGenerator
+		err string
+		// values of the first calls of the generator
+		want []interface{}
+		// substring of the error of the call after want, if any
+		genErr string
+		// check of a random value, instead of want
+		check func(v interface{}) error
+	}{
+		{name: "constant", arg: Arg{Type: ConstantArg, Value: "7"}, typ: "uint8", want: []interface{}{uint8(7), uint8(7)}},
+		{name: "malformed constant", arg: Arg{Type: ConstantArg, Value: "x"}, typ: "uint8", err: "invalid uint8"},
+		{name: "single value range", arg: Arg{Type: RandomUintArg, Min: "5", Max: "5"}, typ: "uint8", want: []interface{}{uint8(5), uint8(5)}},
+		{name: "range at max of type", arg: Arg{Type: RandomUintArg, Min: "255", Max: "255"}, typ: "uint8", want: []interface{}{uint8(255)}},
+		{name: "range out of type", arg: Arg{Type: RandomUintArg, Min: "256", Max: "256"}, typ: "uint8", genErr: "out of range"},
+		{name: "malformed min", arg: Arg{Type: RandomUintArg, Min: "a", Max: "5"}, typ: "uint8", err: "invalid min"},
+		{name: "missing max", arg: Arg{Type: RandomUintArg, Min: "1"}, typ: "uint8", err: "invalid max"},
+		{name: "max below min", arg: Arg{Type: RandomUintArg, Min: "5", Max: "4"}, typ: "uint8", err: "invalid max"},
+		{name: "random account", arg: Arg{Type: RandomAccountArg}, typ: "address", check: isAccount},
+		{name: "random account of uint", arg: Arg{Type: RandomAccountArg}, typ: "uint256", err: "is not address"},
+		{name: "sequence", arg: Arg{Type: SequenceArg, Start: 7}, typ: "uint256", want: []interface{}{big.NewInt(7), big.NewInt(8)}},
+		{name: "sequence overflow", arg: Arg{Type: SequenceArg, Start: 255}, typ: "uint8", want: []interface{}{uint8(255)}, genErr: "out of range"},
+		{name: "random bytes", arg: Arg{Type: RandomBytesArg, Length: 3}, typ: "bytes", check: hasLength(3)},
+		{name: "random fixed bytes", arg: Arg{Type: RandomBytesArg, Length: 1}, typ: "bytes4", check: hasLength(4)},
+		{name: "random bytes of uint", arg: Arg{Type: RandomBytesArg, Length: 1}, typ: "uint256", genErr: "is not bytes"},
+		{name: "unknown type", arg: Arg{Type: "random"}, typ: "uint256", err: "wrong arg type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generate, err := newArgGenerator(tt.arg, mustType(t, tt.typ), accounts)
+			if tt.err != "" || err != nil {
+				checkArg(t, nil, err, nil, tt.err)
+				return
+			}
+			for _, want := range tt.want {
+				got, err := generate()
+				checkArg(t, got, err, want, "")
+			}
+			if tt.check != nil {
+				got, err := generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := tt.check(got); err != nil {
+					t.Error(err)
+				}
+			}
+			if tt.genErr != "" {
+				got, err := generate()
+				checkArg(t, got, err, nil, tt.genErr)
+			}
+		})
+	}
+}
+
+// checkArg checks a generated value, or its error when wantErr is set.
+func checkArg(t *testing.T, got interface{}, err error, want interface{}, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("error = %v, want %q", err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
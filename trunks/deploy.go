@@ -0,0 +1,177 @@
+package trunks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+// margin added to the estimated gas of a deployment when gasLimit is not set
+const deployGasMargin = 1.2
+
+type Deploy struct {
+	// hex bytecode, or path to a compiled artifact JSON
+	Bytecode string `yaml:"bytecode"`
+	// ABI of the constructor when bytecode is hex
+	ABI  string `yaml:"abi,omitempty"`
+	Args []Arg  `yaml:"args,omitempty"`
+}
+
+// makeDeployAttacker sends contract creation transactions and records the
+// code size and gas used of every deployed contract.
+func makeDeployAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*TransactionAttacker, error) {
+	if action.Deploy == nil {
+		return nil, fmt.Errorf("deploy action needs deploy")
+	}
+	payload, err := deployPayload(action.Deploy, t.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ethclient.Dial(t.L2RPC)
+	if err != nil {
+		return nil, err
+	}
+
+	// the deployment is simulated with generators of its own, so that the
+	// attack starts at their first value
+	probe, err := deployPayload(action.Deploy, t.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	deployer := t.Accounts.List[0].Address
+	_, data, err := probe(deployer, deployer)
+	if err != nil {
+		return nil, err
+	}
+	msg := ethereum.CallMsg{From: deployer, Data: data}
+	// a creation call returns the runtime code, of the same size for every
+	// deployment, so the code is not read during the attack
+	code, err := client.CallContract(context.Background(), msg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate deployment: %w", err)
+	}
+
+	if action.GasLimit == 0 {
+		gas, err := client.EstimateGas(context.Background(), msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate deployment gas: %w", err)
+		}
+		withGasLimit := *action
+		withGasLimit.GasLimit = uint64(float64(gas) * deployGasMargin)
+		action = &withGasLimit
+	}
+
+	attacker, err := makeTransactionAttacker(action, t, report, duration, payload, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	attacker.onConfirm = func(receipt *types.Receipt) {
+		report.RecordDeployment(len(code), receipt.GasUsed)
+	}
+	return attacker, nil
+}
+
+// deployPayload builds creation transactions of the bytecode followed by the
+// encoded constructor args.
+func deployPayload(deploy *Deploy, accounts *account.Accounts) (PayloadFunc, error) {
+	bytecode, contractABI, err := loadBytecode(deploy)
+	if err != nil {
+		return nil, err
+	}
+	inputs := contractABI.Constructor.Inputs
+	if len(inputs) != len(deploy.Args) {
+		return nil, fmt.Errorf("constructor needs %d args, got %d", len(inputs), len(deploy.Args))
+	}
+
+	generators := make([]argGenerator, len(deploy.Args))
+	for i, arg := range deploy.Args {
+		generators[i], err = newArgGenerator(arg, inputs[i].Type, accounts)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d of constructor: %w", i, err)
+		}
+	}
+
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		args := make([]interface{}, len(generators))
+		for i, generate := range generators {
+			arg, err := generate()
+			if err != nil {
+				return nil, nil, err
+			}
+			args[i] = arg
+		}
+		packed, err := inputs.Pack(args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, append(common.CopyBytes(bytecode), packed...), nil
+	}, nil
+}
+
+// loadBytecode reads hex bytecode, or the bytecode and abi of a compiled
+// artifact JSON. bytecode of the artifact is either a hex string or an
+// object with the hex string in its object field.
+func loadBytecode(deploy *Deploy) ([]byte, abi.ABI, error) {
+	var contractABI abi.ABI
+	if deploy.ABI != "" {
+		var err error
+		if contractABI, err = loadABI(deploy.ABI); err != nil {
+			return nil, abi.ABI{}, err
+		}
+	}
+	if strings.HasPrefix(deploy.Bytecode, "0x") {
+		bytecode, err := hexutil.Decode(deploy.Bytecode)
+		return bytecode, contractABI, err
+	}
+
+	path := utils.ConvertToAbsPath(deploy.Bytecode)
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, abi.ABI{}, err
+	}
+	var artifact struct {
+		ABI      json.RawMessage `json:"abi"`
+		Bytecode json.RawMessage `json:"bytecode"`
+	}
+	if err := json.Unmarshal(file, &artifact); err != nil {
+		return nil, abi.ABI{}, err
+	}
+	var hex string
+	if err := json.Unmarshal(artifact.Bytecode, &hex); err != nil {
+		var object struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(artifact.Bytecode, &object); err != nil {
+			return nil, abi.ABI{}, fmt.Errorf("no bytecode in %s", path)
+		}
+		hex = object.Object
+	}
+	if !strings.HasPrefix(hex, "0x") {
+		hex = "0x" + hex
+	}
+	bytecode, err := hexutil.Decode(hex)
+	if err != nil {
+		return nil, abi.ABI{}, err
+	}
+	if deploy.ABI == "" && len(artifact.ABI) > 0 {
+		if contractABI, err = loadABI(deploy.Bytecode); err != nil {
+			return nil, abi.ABI{}, err
+		}
+	}
+	return bytecode, contractABI, nil
+}
@@ -0,0 +1,273 @@
+package trunks
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-node/rollup/derive"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const (
+	PortalBridge   = "portal"
+	StandardBridge = "standardBridge"
+)
+
+const (
+	// gas limit of the deposit transaction on L2
+	depositL2GasLimit = 100000
+	// how long a deposit included on L1 is waited for until it is derived on L2
+	depositTimeout = 10 * time.Minute
+)
+
+type DepositAttacker struct {
+	Report    *reporter.Report
+	L1Client  *ethclient.Client
+	L1Tracker *ConfirmTracker
+	L2Tracker *ConfirmTracker
+	Portal    common.Address
+	Nonces    *account.NonceManager
+	Senders   *SenderTracker
+	Pace      vegeta.Pacer
+	Duration  time.Duration
+	Targeter  vegeta.Targeter
+}
+
+func makeDepositAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*DepositAttacker, error) {
+	if t.L1Deployments == nil {
+		return nil, fmt.Errorf("deposit action needs L1 contract addresses file")
+	}
+	if err := action.validateTxType(); err != nil {
+		return nil, err
+	}
+
+	l1Client, err := ethclient.Dial(t.L1RPC)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload PayloadFunc
+	// ETH is sent with the deposit, tokens are approved to the bridge instead
+	var txValue *big.Int
+	if action.Token != "" {
+		payload, err = makeTokenDepositPayload(action, t, l1Client)
+		txValue = big.NewInt(0)
+	} else {
+		switch action.Bridge {
+		case "", PortalBridge:
+			payload, err = portalDepositPayload(t.L1Deployments.OptimismPortalProxy)
+		case StandardBridge:
+			payload, err = standardBridgeDepositPayload(t.L1StandardBridge)
+		default:
+			err = fmt.Errorf("wrong bridge: %q", action.Bridge)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	l2Tracker, err := t.newL2ConfirmTracker()
+	if err != nil {
+		return nil, err
+	}
+	nonces, err := t.nonceManager(t.L1RPC, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	senders := NewSenderTracker()
+
+	tOption := &TargetOption{
+		RPC: t.L1RPC,
+		TransactionOption: &TransactionOption{
+			Accounts: t.Accounts,
+			ChainId:  t.L1ChainId,
+			Client:   l1Client,
+			GasLimit: action.GasLimit,
+			TxType:   action.TxType,
+			Fee:      action.Fee,
+			FeeTTL:   l1BlockTime,
+			Nonces:   nonces,
+			Senders:  senders,
+			Value:    txValue,
+			Payload:  payload,
+		},
+	}
+	return &DepositAttacker{
+		Report:    report,
+		L1Client:  l1Client,
+		L1Tracker: NewConfirmTracker(l1Client, false),
+		L2Tracker: l2Tracker,
+		Portal:    t.L1Deployments.OptimismPortalProxy,
+		Nonces:    nonces,
+		Senders:   senders,
+		Pace:      action.GetPace(),
+		Duration:  duration,
+		Targeter:  TransactionTargeter(tOption),
+	}, nil
+}
+
+// portalDepositPayload deposits value to the sender's L2 address through
+// OptimismPortal.depositTransaction.
+func portalDepositPayload(portal common.Address) (PayloadFunc, error) {
+	portalABI, err := bindings.OptimismPortalMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		data, err := portalABI.Pack("depositTransaction", from, value, uint64(depositL2GasLimit), false, []byte{})
+		return &portal, data, err
+	}, nil
+}
+
+// standardBridgeDepositPayload deposits value to the sender's L2 address
+// through L1StandardBridge.depositETH.
+func standardBridgeDepositPayload(bridge common.Address) (PayloadFunc, error) {
+	bridgeABI, err := bindings.L1StandardBridgeMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := bridgeABI.Pack("depositETH", uint32(depositL2GasLimit), []byte{})
+	if err != nil {
+		return nil, err
+	}
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		return &bridge, data, nil
+	}, nil
+}
+
+// makeTokenDepositPayload approves the L1 token of the action, e.g. TON, from
+// every test account to L1StandardBridge before the attack, so the setup is
+// not measured, and deposits it with depositERC20.
+func makeTokenDepositPayload(action *Action, t *Trunks, l1Client *ethclient.Client) (PayloadFunc, error) {
+	if action.Bridge != "" && action.Bridge != StandardBridge {
+		return nil, fmt.Errorf("token deposits go through %s, not %q", StandardBridge, action.Bridge)
+	}
+	if !common.IsHexAddress(action.Token) {
+		return nil, fmt.Errorf("invalid deposit token address: %q", action.Token)
+	}
+	l1Token := common.HexToAddress(action.Token)
+	l2Token := legacyERC20ETH
+	if action.L2Token != "" {
+		if !common.IsHexAddress(action.L2Token) {
+			return nil, fmt.Errorf("invalid deposit L2 token address: %q", action.L2Token)
+		}
+		l2Token = common.HexToAddress(action.L2Token)
+	}
+	if t.L1StandardBridge == (common.Address{}) {
+		return nil, fmt.Errorf("token deposit needs L1StandardBridge address")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	if err := approveERC20(ctx, l1Client, t.L1ChainId, t.Accounts, l1Token, t.L1StandardBridge); err != nil {
+		return nil, err
+	}
+	// the nonces moved if another action of the step shares them
+	if nonces, ok := t.nonces[t.L1RPC]; ok {
+		for _, address := range t.Accounts.GetAddresses() {
+			if err := nonces.Resync(ctx, address); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return standardBridgeTokenDepositPayload(t.L1StandardBridge, l1Token, l2Token)
+}
+
+// standardBridgeTokenDepositPayload deposits erc20TransferAmount of l1Token
+// to the sender's L2 address through L1StandardBridge.depositERC20.
+func standardBridgeTokenDepositPayload(bridge, l1Token, l2Token common.Address) (PayloadFunc, error) {
+	bridgeABI, err := bindings.L1StandardBridgeMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := bridgeABI.Pack("depositERC20", l1Token, l2Token, erc20TransferAmount, uint32(depositL2GasLimit), []byte{})
+	if err != nil {
+		return nil, err
+	}
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		return &bridge, data, nil
+	}, nil
+}
+
+func (da *DepositAttacker) Attack() <-chan *vegeta.Result {
+	utils.Printf("deposit attack start\n")
+	results := make(chan *vegeta.Result)
+	tReport := da.Report
+
+	if err := da.L1Tracker.Start(context.Background()); err != nil {
+		utils.Printf("failed to start L1 confirm tracker: %s\n", err)
+		close(results)
+		return results
+	}
+	if err := da.L2Tracker.Start(context.Background()); err != nil {
+		utils.Printf("failed to start L2 confirm tracker: %s\n", err)
+		da.L1Tracker.Stop()
+		close(results)
+		return results
+	}
+
+	loop := &confirmLoop{
+		attack:       "deposit attack",
+		report:       tReport,
+		tracker:      da.L1Tracker,
+		nonces:       da.Nonces,
+		senders:      da.Senders,
+		timeoutError: "deposit not included on L1",
+		failedError:  "deposit confirmed failure on L1",
+		confirmed: func(l1 confirmation, result *vegeta.Result) error {
+			tReport.RecordInclusionLatency(reporter.L1InclusionStage, result.Latency)
+
+			depositHash, err := l2DepositTxHash(l1.receipt, da.Portal)
+			if err != nil {
+				return err
+			}
+			l2ConfirmCh := da.L2Tracker.Track(depositHash)
+			select {
+			case l2 := <-l2ConfirmCh:
+				result.Latency = l2.confirmedAt.Sub(result.Timestamp)
+				tReport.RecordInclusionLatency(reporter.L2DerivationStage, l2.confirmedAt.Sub(l1.confirmedAt))
+				if l2.receipt.Status == types.ReceiptStatusSuccessful {
+					tReport.RecordDepositReceipt(l2.receipt)
+				}
+				return nil
+			case <-time.After(depositTimeout):
+				da.L2Tracker.Forget(depositHash)
+				result.Latency = time.Since(result.Timestamp)
+				return fmt.Errorf("deposit not derived on L2")
+			}
+		},
+	}
+	go func() {
+		defer close(results)
+		loop.run(da.Targeter, da.Pace, da.Duration, results)
+		da.L1Tracker.Stop()
+		da.L2Tracker.Stop()
+	}()
+
+	return results
+}
+
+// l2DepositTxHash derives the hash of the L2 deposit transaction from the
+// TransactionDeposited event emitted by OptimismPortal.
+func l2DepositTxHash(receipt *types.Receipt, portal common.Address) (common.Hash, error) {
+	for _, log := range receipt.Logs {
+		if log.Address != portal || len(log.Topics) == 0 || log.Topics[0] != derive.DepositEventABIHash {
+			continue
+		}
+		dep, err := derive.UnmarshalDepositLogEvent(log)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return types.NewTx(dep).Hash(), nil
+	}
+	return common.Hash{}, fmt.Errorf("no deposit event in %s", receipt.TxHash)
+}
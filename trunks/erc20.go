@@ -0,0 +1,158 @@
+package trunks
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+// how long deploying and minting the token before the attack may take
+const setupTimeout = 10 * time.Minute
+
+const (
+	erc20Name     = "Trunks Token"
+	erc20Symbol   = "TRUNKS"
+	erc20Decimals = 18
+)
+
+var (
+	// 1,000,000,000 tokens
+	erc20MintAmount, _ = new(big.Int).SetString("1000000000000000000000000000", 10)
+	// 1 token
+	erc20TransferAmount = big.NewInt(1000000000000000000)
+)
+
+// makeERC20Attacker sends ERC-20 transfers between the test accounts. When
+// the action has no token, a token is deployed and minted to every account
+// before the attack, so the setup is not measured.
+func makeERC20Attacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*TransactionAttacker, error) {
+	token := common.HexToAddress(action.Token)
+	if action.Token == "" {
+		client, err := ethclient.Dial(t.L2RPC)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+		defer cancel()
+		token, err = deployERC20(ctx, client, t.L2ChainId, t.Accounts)
+		if err != nil {
+			return nil, err
+		}
+		// the deployer's nonce moved if another action of the step shares it
+		if nonces, ok := t.nonces[t.L2RPC]; ok {
+			if err := nonces.Resync(ctx, t.Accounts.List[0].Address); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	payload, err := erc20TransferPayload(token)
+	if err != nil {
+		return nil, err
+	}
+	return makeTransactionAttacker(action, t, report, duration, payload, big.NewInt(0))
+}
+
+func erc20TransferPayload(token common.Address) (PayloadFunc, error) {
+	erc20ABI, err := bindings.ERC20MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		data, err := erc20ABI.Pack("transfer", next, erc20TransferAmount)
+		return &token, data, err
+	}, nil
+}
+
+// deployERC20 deploys OptimismMintableERC20 from the first test account,
+// which is set as the bridge so that it can mint to every test account.
+func deployERC20(ctx context.Context, client *ethclient.Client, chainId *big.Int, accounts *account.Accounts) (common.Address, error) {
+	deployer := accounts.List[0]
+	opts, err := bind.NewKeyedTransactorWithChainID(deployer.PrivKey, chainId)
+	if err != nil {
+		return common.Address{}, err
+	}
+	opts.Context = ctx
+
+	address, tx, token, err := bindings.DeployOptimismMintableERC20(
+		opts, client, deployer.Address, common.Address{}, erc20Name, erc20Symbol, erc20Decimals,
+	)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, err := bind.WaitDeployed(ctx, client, tx); err != nil {
+		return common.Address{}, err
+	}
+	utils.Printf("deployed ERC20 token %s\n", address.Hex())
+
+	nonce, err := client.PendingNonceAt(ctx, deployer.Address)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var mintTxs []*types.Transaction
+	for _, a := range accounts.List {
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		tx, err := token.Mint(opts, a.Address, erc20MintAmount)
+		if err != nil {
+			return common.Address{}, err
+		}
+		mintTxs = append(mintTxs, tx)
+		nonce++
+	}
+	for _, tx := range mintTxs {
+		receipt, err := bind.WaitMined(ctx, client, tx)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return common.Address{}, fmt.Errorf("failed to mint ERC20 token: %s", tx.Hash().Hex())
+		}
+	}
+	utils.Printf("minted ERC20 token to %d accounts\n", len(accounts.List))
+
+	return address, nil
+}
+
+// approveERC20 approves spender to spend all the token of every test account.
+func approveERC20(ctx context.Context, client *ethclient.Client, chainId *big.Int, accounts *account.Accounts, token, spender common.Address) error {
+	erc20, err := bindings.NewERC20(token, client)
+	if err != nil {
+		return err
+	}
+	var approveTxs []*types.Transaction
+	for _, a := range accounts.List {
+		opts, err := bind.NewKeyedTransactorWithChainID(a.PrivKey, chainId)
+		if err != nil {
+			return err
+		}
+		opts.Context = ctx
+		tx, err := erc20.Approve(opts, spender, abi.MaxUint256)
+		if err != nil {
+			return err
+		}
+		approveTxs = append(approveTxs, tx)
+	}
+	for _, tx := range approveTxs {
+		receipt, err := bind.WaitMined(ctx, client, tx)
+		if err != nil {
+			return err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("failed to approve ERC20 token: %s", tx.Hash().Hex())
+		}
+	}
+	utils.Printf("approved ERC20 token %s of %d accounts to %s\n", token.Hex(), len(accounts.List), spender.Hex())
+	return nil
+}
@@ -0,0 +1,274 @@
+package trunks
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	LegacyTxType  = "legacy"
+	DynamicTxType = "dynamic"
+	BlobTxType    = "blob"
+)
+
+const (
+	FixedFeeStrategy      = "fixed"
+	BaseFeeFeeStrategy    = "baseFee"
+	FeeHistoryFeeStrategy = "feeHistory"
+)
+
+// default window of eth_feeHistory when the scenario does not set blocks
+const defaultFeeHistoryBlocks = 20
+
+type Fee struct {
+	MaxFeePerGas         *FeeStrategy `yaml:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *FeeStrategy `yaml:"maxPriorityFeePerGas,omitempty"`
+	// only fixed and baseFee (multiple of the blob base fee) for blob transactions
+	MaxFeePerBlobGas *FeeStrategy `yaml:"maxFeePerBlobGas,omitempty"`
+}
+
+// FeeStrategy decides a fee cap from the chain state.
+//
+//   - fixed: Value (wei)
+//   - baseFee: Multiplier * base fee of the latest block
+//   - feeHistory: Percentile of the priority fees paid in the last Blocks blocks
+//
+// For maxFeePerGas the suggested priority fee is added on top of baseFee and
+// feeHistory results, and feeHistory takes the highest base fee in the window.
+type FeeStrategy struct {
+	Strategy   string  `yaml:"strategy"`
+	Value      string  `yaml:"value,omitempty"`
+	Multiplier float64 `yaml:"multiplier,omitempty"`
+	Percentile float64 `yaml:"percentile,omitempty"`
+	Blocks     uint64  `yaml:"blocks,omitempty"`
+}
+
+func (f *Fee) validate() error {
+	if f == nil {
+		return nil
+	}
+	for _, s := range []*FeeStrategy{f.MaxFeePerGas, f.MaxPriorityFeePerGas, f.MaxFeePerBlobGas} {
+		if err := s.validate(); err != nil {
+			return err
+		}
+	}
+	if s := f.MaxFeePerBlobGas; s != nil && s.Strategy == FeeHistoryFeeStrategy {
+		return fmt.Errorf("maxFeePerBlobGas does not support %s strategy", FeeHistoryFeeStrategy)
+	}
+	return nil
+}
+
+func (s *FeeStrategy) validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Strategy {
+	case FixedFeeStrategy:
+		if _, ok := new(big.Int).SetString(s.Value, 10); !ok {
+			return fmt.Errorf("invalid fixed fee value: %q", s.Value)
+		}
+	case BaseFeeFeeStrategy:
+		if s.Multiplier <= 0 {
+			return fmt.Errorf("base fee multiplier must be positive")
+		}
+	case FeeHistoryFeeStrategy:
+		if s.Percentile < 0 || s.Percentile > 100 {
+			return fmt.Errorf("fee history percentile must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("wrong fee strategy: %q", s.Strategy)
+	}
+	return nil
+}
+
+func (s *FeeStrategy) blocks() uint64 {
+	if s.Blocks == 0 {
+		return defaultFeeHistoryBlocks
+	}
+	return s.Blocks
+}
+
+// suggestDynamicFee returns gasTipCap and gasFeeCap for a DynamicFeeTx.
+// Without strategies it follows go-ethereum's bind defaults: the node's
+// suggested tip and a fee cap of 2 * base fee + tip.
+func suggestDynamicFee(ctx context.Context, client *ethclient.Client, fee *Fee) (*big.Int, *big.Int, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("chain does not support dynamic fee transaction")
+	}
+	if fee == nil {
+		fee = &Fee{}
+	}
+
+	var gasTipCap *big.Int
+	switch s := fee.MaxPriorityFeePerGas; {
+	case s == nil:
+		gasTipCap, err = client.SuggestGasTipCap(ctx)
+	case s.Strategy == FixedFeeStrategy:
+		gasTipCap, _ = new(big.Int).SetString(s.Value, 10)
+	case s.Strategy == BaseFeeFeeStrategy:
+		gasTipCap = mulBigFloat(head.BaseFee, s.Multiplier)
+	case s.Strategy == FeeHistoryFeeStrategy:
+		gasTipCap, _, err = feeHistory(ctx, client, s)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var gasFeeCap *big.Int
+	switch s := fee.MaxFeePerGas; {
+	case s == nil:
+		gasFeeCap = new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), gasTipCap)
+	case s.Strategy == FixedFeeStrategy:
+		gasFeeCap, _ = new(big.Int).SetString(s.Value, 10)
+	case s.Strategy == BaseFeeFeeStrategy:
+		gasFeeCap = new(big.Int).Add(mulBigFloat(head.BaseFee, s.Multiplier), gasTipCap)
+	case s.Strategy == FeeHistoryFeeStrategy:
+		var maxBaseFee *big.Int
+		_, maxBaseFee, err = feeHistory(ctx, client, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		gasFeeCap = new(big.Int).Add(maxBaseFee, gasTipCap)
+	}
+
+	if gasFeeCap.Cmp(gasTipCap) < 0 {
+		gasFeeCap = new(big.Int).Set(gasTipCap)
+	}
+	return gasTipCap, gasFeeCap, nil
+}
+
+// suggestBlobFee returns maxFeePerBlobGas for a BlobTx, 2 * blob base fee of
+// the next block by default.
+func suggestBlobFee(ctx context.Context, client *ethclient.Client, fee *Fee) (*big.Int, error) {
+	if fee != nil && fee.MaxFeePerBlobGas != nil && fee.MaxFeePerBlobGas.Strategy == FixedFeeStrategy {
+		blobFeeCap, _ := new(big.Int).SetString(fee.MaxFeePerBlobGas.Value, 10)
+		return blobFeeCap, nil
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if head.ExcessBlobGas == nil || head.BlobGasUsed == nil {
+		return nil, fmt.Errorf("chain does not support blob transaction")
+	}
+	blobBaseFee := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(*head.ExcessBlobGas, *head.BlobGasUsed))
+	multiplier := 2.0
+	if fee != nil && fee.MaxFeePerBlobGas != nil {
+		multiplier = fee.MaxFeePerBlobGas.Multiplier
+	}
+	return mulBigFloat(blobBaseFee, multiplier), nil
+}
+
+// feeHistory returns the average of the given reward percentile and the
+// highest base fee (including the next block) over the strategy window.
+func feeHistory(ctx context.Context, client *ethclient.Client, s *FeeStrategy) (*big.Int, *big.Int, error) {
+	history, err := client.FeeHistory(ctx, s.blocks(), nil, []float64{s.Percentile})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reward := big.NewInt(0)
+	for _, r := range history.Reward {
+		if len(r) > 0 {
+			reward.Add(reward, r[0])
+		}
+	}
+	if len(history.Reward) > 0 {
+		reward.Div(reward, big.NewInt(int64(len(history.Reward))))
+	}
+
+	maxBaseFee := big.NewInt(0)
+	for _, b := range history.BaseFee {
+		if b.Cmp(maxBaseFee) > 0 {
+			maxBaseFee.Set(b)
+		}
+	}
+	return reward, maxBaseFee, nil
+}
+
+// feeOracle caches the fee suggestion for one block time so that
+// generated load does not query the node for every transaction.
+type feeOracle struct {
+	client *ethclient.Client
+	fee    *Fee
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	updatedAt time.Time
+	gasPrice  *big.Int
+	gasTipCap *big.Int
+	gasFeeCap *big.Int
+
+	blobUpdatedAt time.Time
+	blobFeeCap    *big.Int
+}
+
+func newFeeOracle(client *ethclient.Client, fee *Fee, ttl time.Duration) *feeOracle {
+	return &feeOracle{
+		client: client,
+		fee:    fee,
+		ttl:    ttl,
+	}
+}
+
+func (o *feeOracle) expired() bool {
+	return time.Since(o.updatedAt) > o.ttl
+}
+
+func (o *feeOracle) legacy(ctx context.Context) (*big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.gasPrice == nil || o.expired() {
+		gasPrice, err := o.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		o.gasPrice = gasPrice
+		o.updatedAt = time.Now()
+	}
+	return o.gasPrice, nil
+}
+
+func (o *feeOracle) dynamic(ctx context.Context) (*big.Int, *big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.gasFeeCap == nil || o.expired() {
+		gasTipCap, gasFeeCap, err := suggestDynamicFee(ctx, o.client, o.fee)
+		if err != nil {
+			return nil, nil, err
+		}
+		o.gasTipCap, o.gasFeeCap = gasTipCap, gasFeeCap
+		o.updatedAt = time.Now()
+	}
+	return o.gasTipCap, o.gasFeeCap, nil
+}
+
+func (o *feeOracle) blob(ctx context.Context) (*big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.blobFeeCap == nil || time.Since(o.blobUpdatedAt) > o.ttl {
+		blobFeeCap, err := suggestBlobFee(ctx, o.client, o.fee)
+		if err != nil {
+			return nil, err
+		}
+		o.blobFeeCap = blobFeeCap
+		o.blobUpdatedAt = time.Now()
+	}
+	return o.blobFeeCap, nil
+}
+
+func mulBigFloat(x *big.Int, m float64) *big.Int {
+	f := new(big.Float).Mul(new(big.Float).SetInt(x), big.NewFloat(m))
+	result, _ := f.Int(nil)
+	return result
+}
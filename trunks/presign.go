@@ -0,0 +1,244 @@
+package trunks
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+// rounds (one transaction per account) signed before they are pushed to the queue
+const presignBatchRounds = 100
+
+type Presign struct {
+	Count int    `yaml:"count,omitempty"`
+	File  string `yaml:"file,omitempty"`
+}
+
+type txQueue interface {
+	push(rawTxs [][]byte) error
+	pop() ([]byte, error)
+	Close() error
+}
+
+type memoryTxQueue struct {
+	mu     sync.Mutex
+	rawTxs [][]byte
+	pos    int
+}
+
+func (q *memoryTxQueue) push(rawTxs [][]byte) error {
+	q.rawTxs = append(q.rawTxs, rawTxs...)
+	return nil
+}
+
+func (q *memoryTxQueue) pop() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pos >= len(q.rawTxs) {
+		return nil, vegeta.ErrNoTargets
+	}
+	rawTx := q.rawTxs[q.pos]
+	q.rawTxs[q.pos] = nil
+	q.pos++
+	return rawTx, nil
+}
+
+func (q *memoryTxQueue) Close() error {
+	q.rawTxs = nil
+	return nil
+}
+
+// fileTxQueue stores length prefixed raw transactions in a file.
+type fileTxQueue struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	r       *bufio.Reader
+	lenBuff [4]byte
+}
+
+func newFileTxQueue(path string) (*fileTxQueue, error) {
+	file, err := os.Create(utils.ConvertToAbsPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return &fileTxQueue{
+		file: file,
+		w:    bufio.NewWriter(file),
+	}, nil
+}
+
+func (q *fileTxQueue) push(rawTxs [][]byte) error {
+	for _, rawTx := range rawTxs {
+		binary.BigEndian.PutUint32(q.lenBuff[:], uint32(len(rawTx)))
+		if _, err := q.w.Write(q.lenBuff[:]); err != nil {
+			return err
+		}
+		if _, err := q.w.Write(rawTx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewind flushes pushed transactions and starts reading from the beginning.
+func (q *fileTxQueue) rewind() error {
+	if err := q.w.Flush(); err != nil {
+		return err
+	}
+	if _, err := q.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	q.r = bufio.NewReader(q.file)
+	return nil
+}
+
+func (q *fileTxQueue) pop() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := io.ReadFull(q.r, q.lenBuff[:]); err != nil {
+		if err == io.EOF {
+			return nil, vegeta.ErrNoTargets
+		}
+		return nil, err
+	}
+	rawTx := make([]byte, binary.BigEndian.Uint32(q.lenBuff[:]))
+	if _, err := io.ReadFull(q.r, rawTx); err != nil {
+		return nil, err
+	}
+	return rawTx, nil
+}
+
+func (q *fileTxQueue) Close() error {
+	return q.file.Close()
+}
+
+// expectedHits integrates the pacer rate over the attack duration.
+func expectedHits(pacer vegeta.Pacer, duration time.Duration) (int, error) {
+	if pacer == nil {
+		return 0, fmt.Errorf("presign needs a pace or a count")
+	}
+	hits := 0.0
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += time.Second {
+		step := time.Second
+		if duration-elapsed < step {
+			step = duration - elapsed
+		}
+		hits += pacer.Rate(elapsed) * step.Seconds()
+	}
+	return int(math.Ceil(hits)), nil
+}
+
+// presignTransactions signs count transactions per account, in parallel
+// across CPU cores, and queues them ordered by nonce round so that every
+// account sends its transactions in nonce order.
+func presignTransactions(ctx context.Context, opts *TransactionOption, presign *Presign, count int) (txQueue, error) {
+	var queue txQueue
+	if presign.File == "" {
+		queue = &memoryTxQueue{}
+	} else {
+		fileQueue, err := newFileTxQueue(presign.File)
+		if err != nil {
+			return nil, err
+		}
+		queue = fileQueue
+	}
+
+	signer := newTxSigner(opts)
+	numAccounts := len(opts.Accounts.List)
+	for round := 0; round < count; round += presignBatchRounds {
+		rounds := presignBatchRounds
+		if count-round < rounds {
+			rounds = count - round
+		}
+
+		batch := make([][]byte, rounds*numAccounts)
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(runtime.NumCPU())
+		for i := 0; i < numAccounts; i++ {
+			i := i
+			g.Go(func() error {
+				for r := 0; r < rounds; r++ {
+					signedTx, err := signer.sign(gctx, i)
+					if err != nil {
+						return err
+					}
+					rawTx, err := signedTx.MarshalBinary()
+					if err != nil {
+						return err
+					}
+					batch[r*numAccounts+i] = rawTx
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			queue.Close()
+			return nil, err
+		}
+		if err := queue.push(batch); err != nil {
+			queue.Close()
+			return nil, err
+		}
+		utils.Printf("\rPresigned transactions: %d", (round+rounds)*numAccounts)
+	}
+	utils.Printf("\n")
+
+	if fileQueue, ok := queue.(*fileTxQueue); ok {
+		if err := fileQueue.rewind(); err != nil {
+			queue.Close()
+			return nil, err
+		}
+	}
+	return queue, nil
+}
+
+// PresignedTargeter only dequeues transactions signed before the attack.
+// Their fee caps are the fees at presign time and are not refreshed, so a
+// base fee rising during the attack leaves them underpriced.
+func PresignedTargeter(opts *TargetOption, queue txQueue) vegeta.Targeter {
+	accounts := opts.Accounts
+	senders := opts.Senders
+	numAccounts := len(accounts.List)
+	var popped int
+	mutex := sync.Mutex{}
+
+	return func(tgt *vegeta.Target) error {
+		if tgt == nil {
+			return vegeta.ErrNilTarget
+		}
+
+		// transactions are queued by round of one per account, so the
+		// position in the queue gives the sender
+		mutex.Lock()
+		rawTx, err := queue.pop()
+		from := accounts.List[popped%numAccounts]
+		popped++
+		mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		id := senders.add(from.Address)
+
+		tgt.Method = "POST"
+		tgt.URL = rpcRequestURL(opts.RPC, sendRawTransactionMethod, id)
+		tgt.Header = map[string][]string{
+			"Content-type": {"application/json"},
+		}
+		tgt.Body = sendRawTransactionBody(rawTx, id)
+
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package trunks
+
+import (
+	"testing"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+func TestExpectedHits(t *testing.T) {
+	tests := []struct {
+		name     string
+		pacer    vegeta.Pacer
+		duration time.Duration
+		want     int
+		wantErr  bool
+	}{
+		{name: "no pace", duration: time.Second, wantErr: true},
+		{name: "zero duration", pacer: vegeta.Rate{Freq: 10, Per: time.Second}, want: 0},
+		{name: "constant", pacer: vegeta.Rate{Freq: 10, Per: time.Second}, duration: 3 * time.Second, want: 30},
+		{name: "partial second", pacer: vegeta.Rate{Freq: 10, Per: time.Second}, duration: 1500 * time.Millisecond, want: 15},
+		{name: "per minute", pacer: vegeta.Rate{Freq: 60, Per: time.Minute}, duration: 10 * time.Second, want: 10},
+		{name: "rounded up", pacer: vegeta.Rate{Freq: 1, Per: 3 * time.Second}, duration: time.Second, want: 1},
+		{
+			name:     "linear",
+			pacer:    vegeta.LinearPacer{StartAt: vegeta.Rate{Freq: 10, Per: time.Second}, Slope: 1},
+			duration: 3 * time.Second,
+			// 10, 11 and 12 hits in each second
+			want: 33,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expectedHits(tt.pacer, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("hits = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
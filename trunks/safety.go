@@ -0,0 +1,160 @@
+package trunks
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+)
+
+const safetyPollInterval = time.Second
+
+// headLabel is a head following the unsafe L2 head, e.g. the safe head or
+// the latest L2 block proposed to L1. head returns its L2 block number.
+type headLabel struct {
+	stage string
+	head  func(ctx context.Context) (uint64, error)
+}
+
+// HeadTracker records how long it took from sending a confirmed transaction
+// until each label's head reached the block of the transaction.
+type HeadTracker struct {
+	report *reporter.Report
+	labels []headLabel
+
+	mu      sync.Mutex
+	pending map[string]map[uint64][]time.Time
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newHeadTracker(report *reporter.Report, labels ...headLabel) *HeadTracker {
+	pending := make(map[string]map[uint64][]time.Time)
+	for _, label := range labels {
+		pending[label.stage] = make(map[uint64][]time.Time)
+	}
+	return &HeadTracker{
+		report:  report,
+		labels:  labels,
+		pending: pending,
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// NewSafetyTracker follows the safe and finalized L2 heads.
+func NewSafetyTracker(client *ethclient.Client, report *reporter.Report) *HeadTracker {
+	return newHeadTracker(
+		report,
+		headLabel{stage: reporter.SafeStage, head: blockLabelHead(client, rpc.SafeBlockNumber)},
+		headLabel{stage: reporter.FinalizedStage, head: blockLabelHead(client, rpc.FinalizedBlockNumber)},
+	)
+}
+
+func blockLabelHead(client *ethclient.Client, label rpc.BlockNumber) func(context.Context) (uint64, error) {
+	return func(ctx context.Context) (uint64, error) {
+		header, err := client.HeaderByNumber(ctx, big.NewInt(label.Int64()))
+		if err != nil {
+			return 0, err
+		}
+		return header.Number.Uint64(), nil
+	}
+}
+
+func (ht *HeadTracker) Start() {
+	go ht.loop()
+}
+
+// Track registers a transaction sent at sentAt and included in blockNumber.
+func (ht *HeadTracker) Track(blockNumber uint64, sentAt time.Time) {
+	ht.mu.Lock()
+	defer ht.mu.Unlock()
+	for _, pending := range ht.pending {
+		pending[blockNumber] = append(pending[blockNumber], sentAt)
+	}
+}
+
+// Wait blocks until every head reached all tracked transactions or timeout
+// has passed, then stops the tracker.
+func (ht *HeadTracker) Wait(timeout time.Duration) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(safetyPollInterval)
+	defer ticker.Stop()
+
+	for !ht.settled() {
+		select {
+		case <-deadline:
+			ht.Stop()
+			return
+		case <-ticker.C:
+		}
+	}
+	ht.Stop()
+}
+
+func (ht *HeadTracker) Stop() {
+	select {
+	case <-ht.quit:
+	default:
+		close(ht.quit)
+	}
+	<-ht.done
+}
+
+func (ht *HeadTracker) settled() bool {
+	ht.mu.Lock()
+	defer ht.mu.Unlock()
+	for _, pending := range ht.pending {
+		if len(pending) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (ht *HeadTracker) loop() {
+	defer close(ht.done)
+	ticker := time.NewTicker(safetyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ht.quit:
+			return
+		case <-ticker.C:
+			ht.poll()
+		}
+	}
+}
+
+func (ht *HeadTracker) poll() {
+	for _, label := range ht.labels {
+		head, err := label.head(context.Background())
+		if err != nil {
+			continue
+		}
+
+		now := time.Now()
+		ht.mu.Lock()
+		recordStage(ht.report, ht.pending[label.stage], head, now, label.stage)
+		ht.mu.Unlock()
+	}
+}
+
+func recordStage(report *reporter.Report, pending map[uint64][]time.Time, head uint64, now time.Time, stage string) {
+	for blockNumber, sentAts := range pending {
+		if blockNumber > head {
+			continue
+		}
+		for _, sentAt := range sentAts {
+			report.RecordInclusionLatency(stage, now.Sub(sentAt))
+		}
+		delete(pending, blockNumber)
+	}
+}
@@ -1,6 +1,7 @@
 package trunks
 
 import (
+	"fmt"
 	"time"
 
 	vegeta "github.com/tsenart/vegeta/v12/lib"
@@ -13,14 +14,37 @@ type Scenario struct {
 }
 
 type Action struct {
-	Method   string `yaml:"method"`
-	Duration string `yaml:"duration"`
-	Bridge   string `yaml:"bridge,omitempty"`
-	To       string `yaml:"to,omitempty"`
-	Pace     *Pace  `yaml:"pace"`
+	Method   string    `yaml:"method"`
+	Duration string    `yaml:"duration"`
+	Calls    []RPCCall `yaml:"calls,omitempty"`
+	// calls in a JSON-RPC batch per request of call
+	BatchSize int    `yaml:"batchSize,omitempty"`
+	Bridge    string `yaml:"bridge,omitempty"`
+	To        string `yaml:"to,omitempty"`
+	Token     string `yaml:"token,omitempty"`
+	// L2 token of a token deposit, the native token of L2 if omitted
+	L2Token   string        `yaml:"l2Token,omitempty"`
+	GasLimit  uint64        `yaml:"gasLimit,omitempty"`
+	TxType    string        `yaml:"txType,omitempty"`
+	Fee       *Fee          `yaml:"fee,omitempty"`
+	Presign   *Presign      `yaml:"presign,omitempty"`
+	Contract  *ContractCall `yaml:"contract,omitempty"`
+	Deploy    *Deploy       `yaml:"deploy,omitempty"`
+	Blob      *Blob         `yaml:"blob,omitempty"`
+	Subscribe *Subscribe    `yaml:"subscribe,omitempty"`
+	// how long safe, finalized and proposed output heads are followed after the attack
+	SafetyTimeout string `yaml:"safetyTimeout,omitempty"`
+	Pace          *Pace  `yaml:"pace"`
+
+	// actions started at the same time; the other fields are not used
+	Parallel []Action `yaml:"parallel,omitempty"`
 }
 
+// GetPace returns the pacer of the action, or nil for actions without pace.
 func (a *Action) GetPace() vegeta.Pacer {
+	if a.Pace == nil {
+		return nil
+	}
 	if a.Pace.Rate != nil {
 		d, _ := time.ParseDuration(a.Pace.Rate.Per)
 		return vegeta.Rate{Freq: a.Pace.Rate.Freq, Per: d}
@@ -52,3 +76,32 @@ type PLinear struct {
 	Start PRate   `yaml:"start"`
 	Slope float64 `yaml:"slope"`
 }
+
+func (a *Action) validateParallel() error {
+	if a.Method != "" {
+		return fmt.Errorf("parallel step does not take method")
+	}
+	for _, action := range a.Parallel {
+		if len(action.Parallel) > 0 {
+			return fmt.Errorf("parallel step can not be nested")
+		}
+	}
+	return nil
+}
+
+func (a *Action) validateTxType() error {
+	switch a.TxType {
+	case "", LegacyTxType:
+		if a.Fee != nil {
+			return fmt.Errorf("fee is only available with txType %s", DynamicTxType)
+		}
+	case DynamicTxType:
+		if a.Fee != nil && a.Fee.MaxFeePerBlobGas != nil {
+			return fmt.Errorf("maxFeePerBlobGas is only available with txType %s", BlobTxType)
+		}
+		return a.Fee.validate()
+	default:
+		return fmt.Errorf("wrong tx type: %q", a.TxType)
+	}
+	return nil
+}
@@ -6,10 +6,13 @@ import (
 	"os"
 	"sync"
 
+	"github.com/ethereum-optimism/optimism/op-chain-ops/genesis"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 
 	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/metrics"
 	"github.com/tokamak-network/tokamak-trunks/reporter"
 	"github.com/tokamak-network/tokamak-trunks/utils"
 )
@@ -34,7 +37,9 @@ func Main() cli.ActionFunc {
 }
 
 func NewService(cfg *CLIConfig) (*TrunksErvice, error) {
-	initReporter(cfg)
+	if err := initReporter(cfg); err != nil {
+		return nil, err
+	}
 
 	scenario, err := initScenario(cfg.ScenarioFilePath)
 	if err != nil {
@@ -47,13 +52,17 @@ func NewService(cfg *CLIConfig) (*TrunksErvice, error) {
 		return nil, err
 	}
 
+	if err := metrics.Start(cfg.Metrics); err != nil {
+		return nil, err
+	}
+
 	return &TrunksErvice{
 		Trunks: trunks,
 	}, nil
 }
 
-func initReporter(cfg *CLIConfig) {
-	reporter.InitReporter(
+func initReporter(cfg *CLIConfig) error {
+	return reporter.InitReporter(
 		reporter.NewConfig(cfg.Reporter),
 	)
 }
@@ -73,6 +82,21 @@ func initScenario(path string) (*Scenario, error) {
 }
 
 func initTrunks(cfg *CLIConfig, accounts *account.Accounts, scenario *Scenario) (*Trunks, error) {
+	var l1Deployments *genesis.L1Deployments
+	if cfg.L1AddressesFilePath != "" {
+		deployments, err := genesis.NewL1Deployments(utils.ConvertToAbsPath(cfg.L1AddressesFilePath))
+		if err != nil {
+			return nil, err
+		}
+		l1Deployments = deployments
+	}
+	var l1StandardBridge common.Address
+	if cfg.L1StandardBrige != "" {
+		l1StandardBridge = common.HexToAddress(cfg.L1StandardBrige)
+	} else if l1Deployments != nil {
+		l1StandardBridge = l1Deployments.L1StandardBridgeProxy
+	}
+
 	return &Trunks{
 		wg: new(sync.WaitGroup),
 
@@ -80,11 +104,23 @@ func initTrunks(cfg *CLIConfig, accounts *account.Accounts, scenario *Scenario)
 
 		L1RPC: cfg.L1RPC,
 		L2RPC: cfg.L2RPC,
+		L2WS:  cfg.L2WS,
 
 		L1ChainId:   new(big.Int).SetUint64(cfg.L1ChainId),
 		L2ChainId:   new(big.Int).SetUint64(cfg.L2ChainId),
 		L2BlockTime: new(big.Int).SetUint64(cfg.L2BlockTime),
 
+		L1Deployments:       l1Deployments,
+		L1StandardBridge:    l1StandardBridge,
+		L2ToL1MessagePasser: common.HexToAddress(cfg.L2ToL1MessagePasser),
+		Proposer:            common.HexToAddress(cfg.Proposer),
+		FeeVaults: reporter.FeeVaults{
+			BaseFee:   common.HexToAddress(cfg.BaseFeeVault),
+			Sequencer: common.HexToAddress(cfg.SequencerFeeVault),
+			L1Fee:     common.HexToAddress(cfg.L1FeeVault),
+		},
+		Dashboard: cfg.Dashboard,
+
 		Accounts: accounts,
 	}, nil
 }
@@ -95,4 +131,5 @@ func (ts *TrunksErvice) Start() error {
 }
 
 func (ts *TrunksErvice) Stop() {
+	metrics.Stop()
 }
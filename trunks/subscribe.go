@@ -0,0 +1,279 @@
+package trunks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const (
+	NewHeadsSubscription               = "newHeads"
+	LogsSubscription                   = "logs"
+	NewPendingTransactionsSubscription = "newPendingTransactions"
+)
+
+const (
+	// tag of the results of opening a connection
+	connectMethod = "connect"
+	// suffixes of the tags of failed subscriptions and of notifications
+	// without lag, kept apart from the lag of the notifications
+	errorSuffix    = "-error"
+	unlaggedSuffix = "-unlagged"
+	// timeout of opening a connection and subscribing
+	subscribeTimeout = 10 * time.Second
+	// block timestamps kept for the lag of logs
+	maxBlockTimes = 1024
+)
+
+type Subscribe struct {
+	// concurrent WebSocket connections, 1 by default
+	Connections int `yaml:"connections,omitempty"`
+	// subscriptions of each connection, newHeads by default
+	Subscriptions []string `yaml:"subscriptions,omitempty"`
+	// filter of logs subscriptions
+	Address []string   `yaml:"address,omitempty"`
+	Topics  [][]string `yaml:"topics,omitempty"`
+}
+
+// SubscribeAttacker holds WebSocket subscriptions for the duration of the
+// action. Each connection, notification and dropped subscription is a result
+// tagged with its subscription, and the latency of a notification is its lag
+// behind the timestamp of its block. Pending transactions have no block, so
+// only their count is meaningful, and they are tagged apart like failures.
+type SubscribeAttacker struct {
+	URL           string
+	Client        *ethclient.Client
+	Connections   int
+	Subscriptions []string
+	LogsFilter    map[string]interface{}
+	Duration      time.Duration
+
+	blockTimes *blockTimeCache
+}
+
+func makeSubscribeAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*SubscribeAttacker, error) {
+	if t.L2WS == "" {
+		return nil, fmt.Errorf("subscribe action needs L2 WebSocket URL")
+	}
+	sub := action.Subscribe
+	if sub == nil {
+		sub = &Subscribe{}
+	}
+	connections := sub.Connections
+	if connections == 0 {
+		connections = 1
+	}
+	if connections < 0 {
+		return nil, fmt.Errorf("connections must be positive")
+	}
+	subscriptions := sub.Subscriptions
+	if len(subscriptions) == 0 {
+		subscriptions = []string{NewHeadsSubscription}
+	}
+	for _, s := range subscriptions {
+		switch s {
+		case NewHeadsSubscription, LogsSubscription, NewPendingTransactionsSubscription:
+		default:
+			return nil, fmt.Errorf("wrong subscription: %q", s)
+		}
+	}
+
+	filter := make(map[string]interface{})
+	if len(sub.Address) > 0 {
+		filter["address"] = sub.Address
+	}
+	if len(sub.Topics) > 0 {
+		filter["topics"] = sub.Topics
+	}
+
+	client, err := ethclient.Dial(t.L2RPC)
+	if err != nil {
+		return nil, err
+	}
+	return &SubscribeAttacker{
+		URL:           t.L2WS,
+		Client:        client,
+		Connections:   connections,
+		Subscriptions: subscriptions,
+		LogsFilter:    filter,
+		Duration:      duration,
+		blockTimes:    newBlockTimeCache(client),
+	}, nil
+}
+
+func (sa *SubscribeAttacker) Attack() <-chan *vegeta.Result {
+	utils.Printf("subscribe attack start\n")
+	results := make(chan *vegeta.Result)
+	ctx, cancel := context.WithTimeout(context.Background(), sa.Duration)
+	var wg sync.WaitGroup
+
+	for i := 0; i < sa.Connections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sa.connect(ctx, results)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		defer close(results)
+	}()
+
+	return results
+}
+
+// connect opens a connection, subscribes and forwards the notifications
+// until ctx is done.
+func (sa *SubscribeAttacker) connect(ctx context.Context, results chan<- *vegeta.Result) {
+	started := time.Now()
+	dialCtx, cancel := context.WithTimeout(ctx, subscribeTimeout)
+	defer cancel()
+	client, err := rpc.DialContext(dialCtx, sa.URL)
+	results <- sa.result(connectMethod, started, time.Since(started), err)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for _, name := range sa.Subscriptions {
+		args := []interface{}{name}
+		if name == LogsSubscription {
+			args = append(args, sa.LogsFilter)
+		}
+		ch := make(chan json.RawMessage)
+		sub, err := client.Subscribe(dialCtx, "eth", ch, args...)
+		if err != nil {
+			results <- sa.result(name+errorSuffix, time.Now(), 0, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, sub *rpc.ClientSubscription, ch chan json.RawMessage) {
+			defer wg.Done()
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err := <-sub.Err():
+					if err == nil {
+						err = fmt.Errorf("subscription closed")
+					}
+					results <- sa.result(name+errorSuffix, time.Now(), 0, fmt.Errorf("subscription dropped: %w", err))
+					return
+				case msg := <-ch:
+					receivedAt := time.Now()
+					lag, ok := sa.lag(ctx, name, msg, receivedAt)
+					if !ok {
+						results <- sa.result(name+unlaggedSuffix, receivedAt, 0, nil)
+						continue
+					}
+					results <- sa.result(name, receivedAt, lag, nil)
+				}
+			}
+		}(name, sub, ch)
+	}
+	wg.Wait()
+}
+
+// lag returns how long after the timestamp of its block a notification was
+// received, or false for pending transactions and unknown blocks.
+func (sa *SubscribeAttacker) lag(ctx context.Context, name string, msg json.RawMessage, receivedAt time.Time) (time.Duration, bool) {
+	var timestamp uint64
+	switch name {
+	case NewHeadsSubscription:
+		var head struct {
+			Hash common.Hash    `json:"hash"`
+			Time hexutil.Uint64 `json:"timestamp"`
+		}
+		if err := json.Unmarshal(msg, &head); err != nil {
+			return 0, false
+		}
+		sa.blockTimes.add(head.Hash, uint64(head.Time))
+		timestamp = uint64(head.Time)
+	case LogsSubscription:
+		var log struct {
+			BlockHash common.Hash `json:"blockHash"`
+		}
+		if err := json.Unmarshal(msg, &log); err != nil {
+			return 0, false
+		}
+		var err error
+		if timestamp, err = sa.blockTimes.get(ctx, log.BlockHash); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return receivedAt.Sub(time.Unix(int64(timestamp), 0)), true
+}
+
+func (sa *SubscribeAttacker) result(name string, timestamp time.Time, latency time.Duration, err error) *vegeta.Result {
+	result := &vegeta.Result{
+		Attack:    "subscribe attack",
+		Timestamp: timestamp,
+		Latency:   latency,
+		Method:    "WS",
+		URL:       rpcMethodURL(sa.URL, name),
+		Code:      200,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Code = 0
+	}
+	return result
+}
+
+// blockTimeCache keeps the timestamps of recent blocks, filled by newHeads
+// notifications or fetched by hash.
+type blockTimeCache struct {
+	client *ethclient.Client
+
+	mu    sync.Mutex
+	times map[common.Hash]uint64
+}
+
+func newBlockTimeCache(client *ethclient.Client) *blockTimeCache {
+	return &blockTimeCache{
+		client: client,
+		times:  make(map[common.Hash]uint64),
+	}
+}
+
+func (bc *blockTimeCache) add(hash common.Hash, timestamp uint64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if len(bc.times) >= maxBlockTimes {
+		bc.times = make(map[common.Hash]uint64)
+	}
+	bc.times[hash] = timestamp
+}
+
+func (bc *blockTimeCache) get(ctx context.Context, hash common.Hash) (uint64, error) {
+	bc.mu.Lock()
+	timestamp, ok := bc.times[hash]
+	bc.mu.Unlock()
+	if ok {
+		return timestamp, nil
+	}
+	header, err := bc.client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return 0, err
+	}
+	bc.add(hash, header.Time)
+	return header.Time, nil
+}
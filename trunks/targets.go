@@ -3,13 +3,16 @@ package trunks
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/holiman/uint256"
 	vegeta "github.com/tsenart/vegeta/v12/lib"
 
 	"github.com/tokamak-network/tokamak-trunks/account"
@@ -34,27 +37,106 @@ type TransactionOption struct {
 	Client   *ethclient.Client
 	Data     []byte
 	GasLimit uint64
+	TxType   string
+	Fee      *Fee
+	FeeTTL   time.Duration
+	Nonces   *account.NonceManager
+	Senders  *SenderTracker
+	// Value overrides the default amount sent with each transaction
+	Value *big.Int
+	// Payload, when set, decides the recipient and calldata of each transaction
+	Payload PayloadFunc
+	// Sidecar builds the blobs of each transaction when TxType is blob
+	Sidecar SidecarFunc
 }
 
-func CallTargeter(opts *TargetOption) vegeta.Targeter {
+// PayloadFunc returns the recipient (nil for contract creation) and calldata
+// of a transaction sent by from. next is the account after from in round robin.
+type PayloadFunc func(from, next common.Address) (*common.Address, []byte, error)
+
+// SidecarFunc returns the blobs, commitments and proofs of a blob transaction.
+type SidecarFunc func() (*types.BlobTxSidecar, error)
+
+// SenderTracker remembers the sender of each eth_sendRawTransaction request
+// by its JSON-RPC id, so a rejected transaction can be traced back to the
+// account whose nonce has to be resynchronized.
+type SenderTracker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	senders map[uint64]common.Address
+}
+
+func NewSenderTracker() *SenderTracker {
+	return &SenderTracker{
+		senders: make(map[uint64]common.Address),
+	}
+}
+
+func (st *SenderTracker) add(from common.Address) uint64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.nextID++
+	st.senders[st.nextID] = from
+	return st.nextID
+}
+
+func (st *SenderTracker) pop(id uint64) (common.Address, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	from, ok := st.senders[id]
+	delete(st.senders, id)
+	return from, ok
+}
+
+// CallTargeter sends the calls of schedule in round robin, batchSize calls
+// in a JSON-RPC batch per request when batchSize is more than 1.
+func CallTargeter(opts *TargetOption, schedule []*callTemplate, batchSize int) vegeta.Targeter {
 	roundRobin := -1
+	id := uint64(0)
+	mutex := sync.Mutex{}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
 	return func(tgt *vegeta.Target) error {
 		if tgt == nil {
 			return vegeta.ErrNilTarget
 		}
 
+		mutex.Lock()
+		calls := make([]*callTemplate, batchSize)
+		for i := range calls {
+			roundRobin = (roundRobin + 1) % len(schedule)
+			calls[i] = schedule[roundRobin]
+		}
+		firstID := id + 1
+		id += uint64(batchSize)
+		mutex.Unlock()
+
+		method := calls[0].method
+		var body []byte
+		var err error
+		if batchSize == 1 {
+			body, err = calls[0].body(firstID)
+		} else {
+			method = batchMethod(calls)
+			batch := make([]json.RawMessage, batchSize)
+			for i, call := range calls {
+				if batch[i], err = call.body(firstID + uint64(i)); err != nil {
+					return err
+				}
+			}
+			body, err = json.Marshal(batch)
+		}
+		if err != nil {
+			return err
+		}
 		tgt.Method = "POST"
-		tgt.URL = opts.RPC
+		tgt.URL = rpcMethodURL(opts.RPC, method)
 		tgt.Header = map[string][]string{
 			"Content-type": {"application/json"},
 		}
-
-		roundRobin = (roundRobin + 1) % len(CALL_METHOD)
-		body := fmt.Sprintf(
-			`{"jsonrpc": "2.0", "method":"%s", "params": [], "id": 0}`,
-			CALL_METHOD[roundRobin],
-		)
-		tgt.Body = []byte(body)
+		tgt.Body = body
 		return nil
 	}
 }
@@ -65,11 +147,9 @@ var value *big.Int = big.NewInt(1000000000000)
 func TransactionTargeter(opts *TargetOption) vegeta.Targeter {
 	roundRobin := -1
 	RPC := opts.RPC
-	chainId := opts.ChainId
-	client := opts.Client
 	accounts := opts.Accounts
-	data := opts.Data
-	gasLimit := opts.GasLimit
+	senders := opts.Senders
+	signer := newTxSigner(opts.TransactionOption)
 
 	mutex := sync.Mutex{}
 
@@ -83,52 +163,171 @@ func TransactionTargeter(opts *TargetOption) vegeta.Targeter {
 		localRoundRobin := roundRobin
 		mutex.Unlock()
 
-		gasPrice, err := client.SuggestGasPrice(context.Background())
+		signedTx, err := signer.sign(context.Background(), localRoundRobin)
 		if err != nil {
 			return err
 		}
 
 		from := accounts.List[localRoundRobin]
-		var to common.Address
-		if opts.To == "" {
-			to = accounts.List[(localRoundRobin+1)%len(accounts.List)].Address
-		} else {
-			to = common.HexToAddress(opts.To)
+		rawTxBytes, err := signedTx.MarshalBinary()
+		if err != nil {
+			signer.releaseNonce(context.Background(), from.Address, signedTx.Nonce())
+			return err
 		}
 
-		if gasLimit == 0 {
-			gasLimit = uint64(300000)
+		id := senders.add(from.Address)
+		tgt.Method = "POST"
+		tgt.URL = rpcRequestURL(RPC, sendRawTransactionMethod, id)
+		tgt.Header = map[string][]string{
+			"Content-type": {"application/json"},
 		}
-		nonce, err := client.PendingNonceAt(context.Background(), from.Address)
+		tgt.Body = sendRawTransactionBody(rawTxBytes, id)
+
+		return nil
+	}
+}
+
+const sendRawTransactionMethod = "eth_sendRawTransaction"
+
+func sendRawTransactionBody(rawTxBytes []byte, id uint64) []byte {
+	rawTxHex := hex.EncodeToString(rawTxBytes)
+	body := fmt.Sprintf(
+		`{"jsonrpc":"2.0","method":"%s","params":["0x%s"],"id":%d}`,
+		sendRawTransactionMethod,
+		rawTxHex,
+		id,
+	)
+	return []byte(body)
+}
+
+type txSigner struct {
+	opts   *TransactionOption
+	oracle *feeOracle
+	signer types.Signer
+}
+
+func newTxSigner(opts *TransactionOption) *txSigner {
+	return &txSigner{
+		opts:   opts,
+		oracle: newFeeOracle(opts.Client, opts.Fee, opts.FeeTTL),
+		signer: types.NewCancunSigner(opts.ChainId),
+	}
+}
+
+// sign builds and signs the next transaction of the index-th account.
+func (s *txSigner) sign(ctx context.Context, index int) (*types.Transaction, error) {
+	opts := s.opts
+	accounts := opts.Accounts
+
+	from := accounts.List[index]
+	next := accounts.List[(index+1)%len(accounts.List)].Address
+	to := &next
+	if opts.To != "" {
+		address := common.HexToAddress(opts.To)
+		to = &address
+	}
+	data := opts.Data
+	if opts.Payload != nil {
+		var err error
+		to, data, err = opts.Payload(from.Address, next)
 		if err != nil {
-			return err
+			return nil, err
 		}
+	}
+	txValue := value
+	if opts.Value != nil {
+		txValue = opts.Value
+	}
 
-		tx := types.NewTransaction(nonce, to, value, gasLimit, gasPrice, data)
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = uint64(300000)
+	}
 
-		signedTx, err := types.SignTx(tx, types.NewCancunSigner(chainId), from.PrivKey)
+	var build func(nonce uint64) types.TxData
+	switch opts.TxType {
+	case DynamicTxType:
+		gasTipCap, gasFeeCap, err := s.oracle.dynamic(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		rawTxBytes, err := signedTx.MarshalBinary()
+		build = func(nonce uint64) types.TxData {
+			return &types.DynamicFeeTx{
+				ChainID:   opts.ChainId,
+				Nonce:     nonce,
+				GasTipCap: gasTipCap,
+				GasFeeCap: gasFeeCap,
+				Gas:       gasLimit,
+				To:        to,
+				Value:     txValue,
+				Data:      data,
+			}
+		}
+	case BlobTxType:
+		if to == nil || opts.Sidecar == nil {
+			return nil, fmt.Errorf("blob transaction needs recipient and sidecar")
+		}
+		gasTipCap, gasFeeCap, err := s.oracle.dynamic(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		rawTxHex := hex.EncodeToString(rawTxBytes)
-		body := fmt.Sprintf(
-			`{"jsonrpc":"2.0","method":"eth_sendRawTransaction","params":["0x%s"],"id":1}`,
-			rawTxHex,
-		)
-
-		tgt.Method = "POST"
-		tgt.URL = RPC
-		tgt.Header = map[string][]string{
-			"Content-type": {"application/json"},
+		blobFeeCap, err := s.oracle.blob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sidecar, err := opts.Sidecar()
+		if err != nil {
+			return nil, err
+		}
+		build = func(nonce uint64) types.TxData {
+			return &types.BlobTx{
+				ChainID:    uint256.MustFromBig(opts.ChainId),
+				Nonce:      nonce,
+				GasTipCap:  uint256.MustFromBig(gasTipCap),
+				GasFeeCap:  uint256.MustFromBig(gasFeeCap),
+				Gas:        gasLimit,
+				To:         *to,
+				Value:      uint256.MustFromBig(txValue),
+				Data:       data,
+				BlobFeeCap: uint256.MustFromBig(blobFeeCap),
+				BlobHashes: sidecar.BlobHashes(),
+				Sidecar:    sidecar,
+			}
 		}
-		tgt.Body = []byte(body)
+	default:
+		gasPrice, err := s.oracle.legacy(ctx)
+		if err != nil {
+			return nil, err
+		}
+		build = func(nonce uint64) types.TxData {
+			return &types.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: gasPrice,
+				Gas:      gasLimit,
+				To:       to,
+				Value:    txValue,
+				Data:     data,
+			}
+		}
+	}
 
-		return nil
+	// the nonce is taken last, so that a failure above does not leave a gap
+	nonce, err := opts.Nonces.Next(ctx, from.Address)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := types.SignTx(types.NewTx(build(nonce)), s.signer, from.PrivKey)
+	if err != nil {
+		s.releaseNonce(ctx, from.Address, nonce)
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// releaseNonce hands back the nonce of a transaction which will not be sent,
+// or resyncs the account when a later nonce was taken meanwhile.
+func (s *txSigner) releaseNonce(ctx context.Context, from common.Address, nonce uint64) {
+	if !s.opts.Nonces.Release(from, nonce) {
+		s.opts.Nonces.Resync(ctx, from)
 	}
 }
@@ -0,0 +1,247 @@
+package trunks
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const (
+	blockPollInterval = 250 * time.Millisecond
+	// blocks whose receipts are kept for transactions tracked after inclusion
+	recentBlockWindow = 64
+	// heads a block's receipts are requested at before the receipts of its
+	// transactions are requested one by one instead
+	blockReceiptsRetries = 3
+	// JSON-RPC error code of a method the node does not serve
+	methodNotFoundCode = -32601
+)
+
+type confirmation struct {
+	receipt     *types.Receipt
+	confirmedAt time.Time
+}
+
+// ConfirmTracker follows new L2 blocks, fetches the receipts of each block
+// once and hands them to the transactions waiting for confirmation.
+type ConfirmTracker struct {
+	client    *ethclient.Client
+	subscribe bool
+
+	mu           sync.Mutex
+	next         uint64
+	pending      map[common.Hash]chan confirmation
+	recent       map[common.Hash]confirmation
+	recentBlocks map[uint64][]common.Hash
+
+	// failed requests of the receipts of block next
+	failures int
+	// receipts of every block are requested by transaction, since the node
+	// does not serve eth_getBlockReceipts
+	byTransaction bool
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewConfirmTracker subscribes to new heads when client is connected over
+// WebSocket (subscribe), otherwise it polls the block number.
+func NewConfirmTracker(client *ethclient.Client, subscribe bool) *ConfirmTracker {
+	return &ConfirmTracker{
+		client:       client,
+		subscribe:    subscribe,
+		pending:      make(map[common.Hash]chan confirmation),
+		recent:       make(map[common.Hash]confirmation),
+		recentBlocks: make(map[uint64][]common.Hash),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+func (ct *ConfirmTracker) Start(ctx context.Context) error {
+	head, err := ct.client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	ct.next = head + 1
+
+	go ct.loop()
+	return nil
+}
+
+func (ct *ConfirmTracker) Stop() {
+	close(ct.quit)
+	<-ct.done
+}
+
+// Track returns a channel which receives the receipt of txHash once the
+// transaction is included in a block.
+func (ct *ConfirmTracker) Track(txHash common.Hash) <-chan confirmation {
+	ch := make(chan confirmation, 1)
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if c, ok := ct.recent[txHash]; ok {
+		ch <- c
+		return ch
+	}
+	ct.pending[txHash] = ch
+	return ch
+}
+
+// Forget stops tracking txHash, e.g. when waiting for it timed out.
+func (ct *ConfirmTracker) Forget(txHash common.Hash) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	delete(ct.pending, txHash)
+}
+
+func (ct *ConfirmTracker) loop() {
+	defer close(ct.done)
+
+	if ct.subscribe {
+		if err := ct.subscribeHeads(); err == nil {
+			return
+		}
+	}
+	ct.pollHeads()
+}
+
+// subscribeHeads returns nil when the tracker stopped, otherwise the
+// subscription error so that the tracker falls back to polling.
+func (ct *ConfirmTracker) subscribeHeads() error {
+	headCh := make(chan *types.Header, 16)
+	sub, err := ct.client.SubscribeNewHead(context.Background(), headCh)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ct.quit:
+			return nil
+		case err := <-sub.Err():
+			return err
+		case head := <-headCh:
+			ct.scan(head.Number.Uint64(), time.Now())
+		}
+	}
+}
+
+func (ct *ConfirmTracker) pollHeads() {
+	ticker := time.NewTicker(blockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ct.quit:
+			return
+		case <-ticker.C:
+			head, err := ct.client.BlockNumber(context.Background())
+			if err != nil {
+				continue
+			}
+			ct.scan(head, time.Now())
+		}
+	}
+}
+
+// scan fetches the receipts of every block up to head which has not been
+// scanned yet. A failed block is retried with the next heads, then the
+// receipts of its transactions are requested one by one instead, so that the
+// tracked transactions are not left waiting for a block which never
+// succeeds.
+func (ct *ConfirmTracker) scan(head uint64, seenAt time.Time) {
+	for ct.next <= head {
+		var receipts []*types.Receipt
+		var err error
+		if ct.byTransaction {
+			receipts, err = ct.transactionReceipts(ct.next)
+		} else {
+			number := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(ct.next))
+			receipts, err = ct.client.BlockReceipts(context.Background(), number)
+			var rpcErr rpc.Error
+			if err != nil && errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+				utils.Printf("eth_getBlockReceipts is not served, requesting receipts by transaction\n")
+				ct.byTransaction = true
+				continue
+			}
+		}
+		if err != nil {
+			ct.failures++
+			if ct.failures < blockReceiptsRetries {
+				return
+			}
+			if !ct.byTransaction {
+				utils.Printf("failed to get receipts of block %d: %s, requesting receipts by transaction\n", ct.next, err)
+				receipts, err = ct.transactionReceipts(ct.next)
+			}
+			if err != nil {
+				utils.Printf("failed to get receipts of block %d: %s, skipping it\n", ct.next, err)
+			}
+		}
+		if err == nil {
+			ct.match(ct.next, receipts, seenAt)
+		}
+		ct.failures = 0
+		ct.next++
+	}
+}
+
+// transactionReceipts requests the receipts of the transactions of block
+// number one by one.
+func (ct *ConfirmTracker) transactionReceipts(number uint64) ([]*types.Receipt, error) {
+	block, err := ct.client.BlockByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		receipt, err := ct.client.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+func (ct *ConfirmTracker) match(blockNumber uint64, receipts []*types.Receipt, seenAt time.Time) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	var hashes []common.Hash
+	for _, receipt := range receipts {
+		c := confirmation{
+			receipt:     receipt,
+			confirmedAt: seenAt,
+		}
+		if ch, ok := ct.pending[receipt.TxHash]; ok {
+			ch <- c
+			delete(ct.pending, receipt.TxHash)
+			continue
+		}
+		ct.recent[receipt.TxHash] = c
+		hashes = append(hashes, receipt.TxHash)
+	}
+	ct.recentBlocks[blockNumber] = hashes
+
+	if blockNumber < recentBlockWindow {
+		return
+	}
+	expired := blockNumber - recentBlockWindow
+	for _, hash := range ct.recentBlocks[expired] {
+		delete(ct.recent, hash)
+	}
+	delete(ct.recentBlocks, expired)
+}
@@ -1,15 +1,23 @@
 package trunks
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ethereum-optimism/optimism/op-chain-ops/genesis"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	vegeta "github.com/tsenart/vegeta/v12/lib"
 
 	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/dashboard"
+	"github.com/tokamak-network/tokamak-trunks/metrics"
 	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
 )
 
 type Trunks struct {
@@ -19,35 +27,215 @@ type Trunks struct {
 
 	L1RPC string
 	L2RPC string
+	L2WS  string
 
 	L1ChainId   *big.Int
 	L2ChainId   *big.Int
 	L2BlockTime *big.Int
 
+	L1Deployments       *genesis.L1Deployments
+	L1StandardBridge    common.Address
+	L2ToL1MessagePasser common.Address
+	Proposer            common.Address
+	FeeVaults           reporter.FeeVaults
+	// whether a dashboard shows the progress of the actions
+	Dashboard bool
+
 	Accounts *account.Accounts
+
+	// nonce managers shared by the actions of a step, by RPC URL
+	nonces map[string]*account.NonceManager
+}
+
+// default fee cache lifetime when the L2 block time is not configured
+const defaultBlockTime = 2 * time.Second
+
+// fee cache lifetime of the L1 actions, the slot time of Ethereum
+const l1BlockTime = 12 * time.Second
+
+func (t *Trunks) blockTime() time.Duration {
+	if t.L2BlockTime == nil || t.L2BlockTime.Sign() == 0 {
+		return defaultBlockTime
+	}
+	return time.Duration(t.L2BlockTime.Int64()) * time.Second
+}
+
+// nonceManager returns the nonce manager of the test accounts on rpc, shared
+// by the actions running at the same time so that their nonces do not collide.
+func (t *Trunks) nonceManager(rpc string, client *ethclient.Client) (*account.NonceManager, error) {
+	if t.nonces == nil {
+		t.nonces = make(map[string]*account.NonceManager)
+	}
+	if nonces, ok := t.nonces[rpc]; ok {
+		return nonces, nil
+	}
+	nonces, err := account.NewNonceManager(context.Background(), client, t.Accounts.GetAddresses())
+	if err != nil {
+		return nil, err
+	}
+	t.nonces[rpc] = nonces
+	return nonces, nil
+}
+
+// newL2ConfirmTracker subscribes new L2 blocks over WebSocket when it is
+// configured, otherwise it polls the L2 RPC.
+func (t *Trunks) newL2ConfirmTracker() (*ConfirmTracker, error) {
+	if t.L2WS == "" {
+		client, err := ethclient.Dial(t.L2RPC)
+		if err != nil {
+			return nil, err
+		}
+		return NewConfirmTracker(client, false), nil
+	}
+	client, err := ethclient.Dial(t.L2WS)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfirmTracker(client, true), nil
 }
 
 func (t *Trunks) Start() error {
-	for _, action := range t.Scenario.Actions {
-		var metrics vegeta.Metrics
-		fmt.Printf("start action %s\n", action.Method)
-		attacker, err := MakeAttacker(&action, t)
+	for i, step := range t.Scenario.Actions {
+		actions := []Action{step}
+		if len(step.Parallel) > 0 {
+			if err := step.validateParallel(); err != nil {
+				return err
+			}
+			actions = step.Parallel
+		}
+		if err := t.runStep(i+1, actions); err != nil {
+			reporter.GetReportManager().Close()
+			return err
+		}
+	}
+	return reporter.GetReportManager().Close()
+}
+
+// runStep runs the actions at the same time, each with its own pacer and
+// report, and reports them separately, plus a timeline of them side by side.
+func (t *Trunks) runStep(step int, actions []Action) error {
+	t.nonces = nil
+	names := actionNames(actions)
+	reports := make([]*reporter.Report, len(actions))
+	attackers := make([]Attacker, len(actions))
+	for i := range actions {
+		utils.Printf("start action %s\n", names[i])
+		reports[i] = reporter.NewReport(t.L2BlockTime)
+		attacker, err := MakeAttacker(&actions[i], t, reports[i])
 		if err != nil {
 			return err
 		}
-		for res := range attacker.Attack() {
-			metrics.Add(res)
+		attackers[i] = attacker
+	}
+
+	client, err := ethclient.Dial(t.L2RPC)
+	if err != nil {
+		return err
+	}
+	// the fee vaults are reconciled on a best-effort basis, without them
+	// the reports only lack their section
+	vaults, err := reporter.ReadVaultBalances(client, t.FeeVaults)
+	if err != nil {
+		utils.Printf("failed to read fee vaults: %s\n", err)
+		vaults = nil
+	}
+
+	actionMetrics := make([]vegeta.Metrics, len(actions))
+	methodMetrics := make([]*reporter.MethodMetrics, len(actions))
+	rm := reporter.GetReportManager()
+	timeline := rm.NewTimeline(names)
+	start := time.Now()
+	timeline.Start(start)
+	capture, err := rm.CaptureStep(step, names, reports, start)
+	if err != nil {
+		return err
+	}
+	defer capture.Close()
+	var board *dashboard.Dashboard
+	boardActions := make([]*dashboard.Action, len(actions))
+	if t.Dashboard {
+		board = dashboard.New(t.blockTime())
+		for i := range actions {
+			duration, _ := time.ParseDuration(actions[i].Duration)
+			boardActions[i] = board.Add(names[i], duration, actions[i].GetPace(), reports[i])
 		}
+	}
+	board.Start(start)
+	// results of all the actions, printed as progress without dashboard
+	var attackCount atomic.Int64
+	var wg sync.WaitGroup
+	for i, attacker := range attackers {
+		methodMetrics[i] = reporter.NewMethodMetrics()
+		live := metrics.NewAction(step, names[i], actions[i].GetPace(), reports[i])
+		live.Start(start)
+		wg.Add(1)
+		go func(i int, attacker Attacker) {
+			defer wg.Done()
+			defer live.Stop()
+			for res := range attacker.Attack() {
+				if board == nil {
+					utils.Printf("\rAttack count: %d", attackCount.Add(1))
+				}
+				capture.Result(i, res)
+				live.Result(res)
+				boardActions[i].Result(res)
+				actionMetrics[i].Add(res)
+				methodMetrics[i].Add(reporter.RPCMethod(res), res)
+				timeline.Add(names[i], res)
+			}
+		}(i, attacker)
+	}
+	wg.Wait()
+	board.Stop()
+	if board == nil {
+		utils.Printf("\n")
+	}
 
-		metrics.Close()
-		vReporter := vegeta.NewTextReporter(&metrics)
-		reporter.GetReportManager().Report(vReporter, action.Method)
+	if vaults != nil {
+		if err := vaults.Close(client); err != nil {
+			utils.Printf("failed to reconcile fee vaults: %s\n", err)
+			vaults = nil
+		}
+	}
+	for i := range actions {
+		actionMetrics[i].Close()
+		methodMetrics[i].Close()
+		if err := reports[i].RecordTPS(client); err != nil {
+			return err
+		}
+		if err := reports[i].RecordL2FeeBreakdown(client); err != nil {
+			return err
+		}
+		if err := reports[i].RecordBlocks(client); err != nil {
+			return err
+		}
+		if err := rm.ReportAction(step, names[i], &actionMetrics[i], methodMetrics[i], reports[i]); err != nil {
+			return err
+		}
+	}
+	if vaults != nil {
+		if err := rm.ReportFeeVaults(step, vaults, reports); err != nil {
+			return err
+		}
+	}
+	return rm.ReportTimeline(step, timeline)
+}
 
-		client, _ := ethclient.Dial(t.L2RPC)
-		tReport := reporter.GetTrunksReport()
-		tReport.RecordTPS(client)
-		reporter.GetReportManager().Report(reporter.TrunksReporter(), "Transaction report")
-		reporter.GetReportManager().Close()
+// actionNames names actions by their method, numbered when a method is
+// repeated in the same step.
+func actionNames(actions []Action) []string {
+	counts := make(map[string]int)
+	for _, action := range actions {
+		counts[action.Method]++
+	}
+	seen := make(map[string]int)
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = action.Method
+		if counts[action.Method] > 1 {
+			seen[action.Method]++
+			names[i] = fmt.Sprintf("%s-%d", action.Method, seen[action.Method])
+		}
 	}
-	return nil
+	return names
 }
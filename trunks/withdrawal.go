@@ -0,0 +1,346 @@
+package trunks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/bindings"
+	"github.com/ethereum-optimism/optimism/op-bindings/predeploys"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+
+	"github.com/tokamak-network/tokamak-trunks/account"
+	"github.com/tokamak-network/tokamak-trunks/reporter"
+	"github.com/tokamak-network/tokamak-trunks/utils"
+)
+
+const MessagePasserBridge = "messagePasser"
+
+const (
+	// gas limit of the withdrawal message on L1
+	withdrawalGasLimit = 100000
+	// how long proposed outputs are followed after the attack by default
+	outputTimeout = 10 * time.Minute
+)
+
+// token address of the native token on L2StandardBridge, TON on Tokamak
+var legacyERC20ETH = common.HexToAddress("0xDeadDeAddeAddEAddeadDEaDDEAdDeaDDeAD0000")
+
+type WithdrawalAttacker struct {
+	Report        *reporter.Report
+	Client        *ethclient.Client
+	Tracker       *ConfirmTracker
+	Outputs       *HeadTracker
+	OutputTimeout time.Duration
+	MessagePasser *bindings.L2ToL1MessagePasserFilterer
+	Proposer      *proposerWatcher
+	Nonces        *account.NonceManager
+	Senders       *SenderTracker
+	Pace          vegeta.Pacer
+	Duration      time.Duration
+	Targeter      vegeta.Targeter
+
+	mu               sync.Mutex
+	withdrawalHashes []common.Hash
+}
+
+func makeWithdrawalAttacker(action *Action, t *Trunks, report *reporter.Report, duration time.Duration) (*WithdrawalAttacker, error) {
+	if t.L1Deployments == nil {
+		return nil, fmt.Errorf("withdrawal action needs L1 contract addresses file")
+	}
+	if err := action.validateTxType(); err != nil {
+		return nil, err
+	}
+
+	var payload PayloadFunc
+	var err error
+	switch action.Bridge {
+	case "", MessagePasserBridge:
+		payload, err = messagePasserWithdrawalPayload(t.L2ToL1MessagePasser)
+	case StandardBridge:
+		payload, err = standardBridgeWithdrawalPayload(predeploys.L2StandardBridgeAddr)
+	default:
+		err = fmt.Errorf("wrong bridge: %q", action.Bridge)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := ethclient.Dial(t.L2RPC)
+	if err != nil {
+		return nil, err
+	}
+	l1Client, err := ethclient.Dial(t.L1RPC)
+	if err != nil {
+		return nil, err
+	}
+	oracle, err := bindings.NewL2OutputOracleCaller(t.L1Deployments.L2OutputOracleProxy, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	messagePasser, err := bindings.NewL2ToL1MessagePasserFilterer(t.L2ToL1MessagePasser, client)
+	if err != nil {
+		return nil, err
+	}
+	proposer, err := newProposerWatcher(l1Client, oracle, t.Proposer)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := t.newL2ConfirmTracker()
+	if err != nil {
+		return nil, err
+	}
+	timeout := outputTimeout
+	if action.SafetyTimeout != "" {
+		timeout, err = time.ParseDuration(action.SafetyTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	nonces, err := t.nonceManager(t.L2RPC, client)
+	if err != nil {
+		return nil, err
+	}
+	senders := NewSenderTracker()
+
+	tOption := &TargetOption{
+		RPC: t.L2RPC,
+		TransactionOption: &TransactionOption{
+			Accounts: t.Accounts,
+			ChainId:  t.L2ChainId,
+			Client:   client,
+			GasLimit: action.GasLimit,
+			TxType:   action.TxType,
+			Fee:      action.Fee,
+			FeeTTL:   t.blockTime(),
+			Nonces:   nonces,
+			Senders:  senders,
+			Payload:  payload,
+		},
+	}
+	return &WithdrawalAttacker{
+		Report:        report,
+		Client:        client,
+		Tracker:       tracker,
+		Outputs:       NewOutputTracker(oracle, report),
+		OutputTimeout: timeout,
+		MessagePasser: messagePasser,
+		Proposer:      proposer,
+		Nonces:        nonces,
+		Senders:       senders,
+		Pace:          action.GetPace(),
+		Duration:      duration,
+		Targeter:      TransactionTargeter(tOption),
+	}, nil
+}
+
+// messagePasserWithdrawalPayload withdraws value to the sender's L1 address
+// through L2ToL1MessagePasser.initiateWithdrawal.
+func messagePasserWithdrawalPayload(messagePasser common.Address) (PayloadFunc, error) {
+	messagePasserABI, err := bindings.L2ToL1MessagePasserMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		data, err := messagePasserABI.Pack("initiateWithdrawal", from, big.NewInt(withdrawalGasLimit), []byte{})
+		return &messagePasser, data, err
+	}, nil
+}
+
+// standardBridgeWithdrawalPayload withdraws value to the sender's L1 address
+// through L2StandardBridge.withdraw.
+func standardBridgeWithdrawalPayload(bridge common.Address) (PayloadFunc, error) {
+	bridgeABI, err := bindings.L2StandardBridgeMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := bridgeABI.Pack("withdraw", legacyERC20ETH, value, uint32(withdrawalGasLimit), []byte{})
+	if err != nil {
+		return nil, err
+	}
+	return func(from, next common.Address) (*common.Address, []byte, error) {
+		return &bridge, data, nil
+	}, nil
+}
+
+// NewOutputTracker follows the latest L2 block proposed to L2OutputOracle.
+func NewOutputTracker(oracle *bindings.L2OutputOracleCaller, report *reporter.Report) *HeadTracker {
+	return newHeadTracker(report, headLabel{
+		stage: reporter.OutputProposalStage,
+		head: func(ctx context.Context) (uint64, error) {
+			latest, err := oracle.LatestBlockNumber(&bind.CallOpts{Context: ctx})
+			if err != nil {
+				return 0, err
+			}
+			return latest.Uint64(), nil
+		},
+	})
+}
+
+// proposerWatcher compares the proposer's L1 account and L2OutputOracle
+// before and after an action.
+type proposerWatcher struct {
+	client   *ethclient.Client
+	oracle   *bindings.L2OutputOracleCaller
+	proposer common.Address
+
+	outputs *big.Int
+	nonce   uint64
+	balance *big.Int
+}
+
+func newProposerWatcher(client *ethclient.Client, oracle *bindings.L2OutputOracleCaller, proposer common.Address) (*proposerWatcher, error) {
+	if proposer == (common.Address{}) {
+		var err error
+		proposer, err = oracle.PROPOSER(&bind.CallOpts{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &proposerWatcher{
+		client:   client,
+		oracle:   oracle,
+		proposer: proposer,
+	}, nil
+}
+
+func (pw *proposerWatcher) snapshot(ctx context.Context) (*big.Int, uint64, *big.Int, error) {
+	outputs, err := pw.oracle.NextOutputIndex(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	nonce, err := pw.client.NonceAt(ctx, pw.proposer, nil)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	balance, err := pw.client.BalanceAt(ctx, pw.proposer, nil)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return outputs, nonce, balance, nil
+}
+
+func (pw *proposerWatcher) start(ctx context.Context) error {
+	outputs, nonce, balance, err := pw.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	pw.outputs, pw.nonce, pw.balance = outputs, nonce, balance
+	return nil
+}
+
+func (pw *proposerWatcher) record(ctx context.Context, report *reporter.Report) error {
+	outputs, nonce, balance, err := pw.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	report.RecordProposer(
+		new(big.Int).Sub(outputs, pw.outputs).Uint64(),
+		nonce-pw.nonce,
+		new(big.Int).Sub(pw.balance, balance),
+	)
+	return nil
+}
+
+func (wa *WithdrawalAttacker) Attack() <-chan *vegeta.Result {
+	utils.Printf("withdrawal attack start\n")
+	results := make(chan *vegeta.Result)
+	tReport := wa.Report
+
+	if err := wa.Proposer.start(context.Background()); err != nil {
+		utils.Printf("failed to read proposer: %s\n", err)
+		close(results)
+		return results
+	}
+	if err := wa.Tracker.Start(context.Background()); err != nil {
+		utils.Printf("failed to start confirm tracker: %s\n", err)
+		close(results)
+		return results
+	}
+	wa.Outputs.Start()
+
+	loop := &confirmLoop{
+		attack:       "withdrawal attack",
+		report:       tReport,
+		tracker:      wa.Tracker,
+		nonces:       wa.Nonces,
+		senders:      wa.Senders,
+		timeoutError: context.DeadlineExceeded.Error(),
+		failedError:  "withdrawal confirmed failure",
+		confirmed: func(c confirmation, result *vegeta.Result) error {
+			tReport.RecordReceipt(c.receipt)
+			tReport.RecordConfirmRequest()
+			tReport.RecordInclusionLatency(reporter.UnsafeStage, result.Latency)
+
+			withdrawalHash, err := wa.withdrawalHash(c.receipt)
+			if err != nil {
+				return err
+			}
+			wa.mu.Lock()
+			wa.withdrawalHashes = append(wa.withdrawalHashes, withdrawalHash)
+			wa.mu.Unlock()
+			tReport.RecordInitiatedWithdrawal()
+			wa.Outputs.Track(c.receipt.BlockNumber.Uint64(), result.Timestamp)
+			return nil
+		},
+	}
+	go func() {
+		defer close(results)
+		loop.run(wa.Targeter, wa.Pace, wa.Duration, results)
+		wa.Tracker.Stop()
+		wa.Outputs.Wait(wa.OutputTimeout)
+		if err := wa.Proposer.record(context.Background(), wa.Report); err != nil {
+			utils.Printf("failed to read proposer: %s\n", err)
+		}
+		if err := writeWithdrawalHashes(wa.withdrawalHashes); err != nil {
+			utils.Printf("failed to write withdrawal hashes: %s\n", err)
+		}
+	}()
+
+	return results
+}
+
+func (wa *WithdrawalAttacker) withdrawalHash(receipt *types.Receipt) (common.Hash, error) {
+	for _, log := range receipt.Logs {
+		event, err := wa.MessagePasser.ParseMessagePassed(*log)
+		if err != nil {
+			continue
+		}
+		return event.WithdrawalHash, nil
+	}
+	return common.Hash{}, fmt.Errorf("no MessagePassed event in %s", receipt.TxHash)
+}
+
+// writeWithdrawalHashes appends the initiated withdrawal hashes to
+// ~/.tokamak-trunks/withdrawals so that they can be proven later.
+func writeWithdrawalHashes(hashes []common.Hash) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	dir := fmt.Sprintf("%s/%s", homeDir, ".tokamak-trunks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(dir+"/withdrawals", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, hash := range hashes {
+		if _, err := writer.WriteString(hash.Hex() + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	printerMu sync.Mutex
+	printer   func(line string)
+)
+
+// SetPrinter routes the lines of Printf through p, e.g. above a dashboard
+// redrawn in place, until it is called again with nil.
+func SetPrinter(p func(line string)) {
+	printerMu.Lock()
+	defer printerMu.Unlock()
+	printer = p
+}
+
+// Printf writes a progress or error line to stdout, or through the printer
+// while one is set.
+func Printf(format string, a ...interface{}) {
+	line := fmt.Sprintf(format, a...)
+	printerMu.Lock()
+	p := printer
+	printerMu.Unlock()
+	if p == nil {
+		fmt.Fprint(os.Stdout, line)
+		return
+	}
+	p(line)
+}